@@ -0,0 +1,119 @@
+/*
+Audit prints the audit log of the WASAPhoto database: logins, username changes, bans, deletions and admin actions.
+Events are printed newest first, one per line.
+
+Usage:
+
+	audit [flags]
+
+The flags are:
+
+	-db-filename <path>
+		Path of the SQLite database (default /tmp/decaf.db). The database must exist, and is opened read-only.
+	-actor <user ID>
+		Only print the actions taken by this user.
+	-action <action>
+		Only print the events of this kind (e.g., ban, photo-delete, role-change).
+	-target-type <type>
+		Only print the events about this kind of target (user, photo, comment, report).
+	-target <ID>
+		Only print the events about this target.
+	-since <RFC3339 date>
+		Only print the events recorded at or after this date.
+	-until <RFC3339 date>
+		Only print the events recorded before this date.
+	-limit <n>
+		Maximum number of events to print (default 100).
+	-offset <n>
+		Number of events to skip.
+
+Return values (exit codes):
+
+	0
+		The events were printed
+
+	> 0
+		The database could not be read, or a flag is invalid
+*/
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/database"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	if err := run(); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "error: ", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func run() error {
+	var filter database.AuditFilter
+	var dbFilename = flag.String("db-filename", "/tmp/decaf.db", "SQLite database path")
+	var since = flag.String("since", "", "only events recorded at or after this date (RFC3339)")
+	var until = flag.String("until", "", "only events recorded before this date (RFC3339)")
+	flag.IntVar(&filter.ActorID, "actor", 0, "only actions taken by this user")
+	flag.StringVar(&filter.Action, "action", "", "only events of this kind")
+	flag.StringVar(&filter.TargetType, "target-type", "", "only events about this kind of target")
+	flag.IntVar(&filter.TargetID, "target", 0, "only events about this target")
+	flag.IntVar(&filter.Limit, "limit", 100, "maximum number of events")
+	flag.IntVar(&filter.Offset, "offset", 0, "number of events to skip")
+
+	flag.Parse()
+
+	var err error
+	if *since != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, *since); err != nil {
+			return fmt.Errorf("invalid since date: %w", err)
+		}
+	}
+	if *until != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, *until); err != nil {
+			return fmt.Errorf("invalid until date: %w", err)
+		}
+	}
+
+	// Never create an empty database, nor change an existing one.
+	if _, err := os.Stat(*dbFilename); err != nil {
+		return fmt.Errorf("opening SQLite: %w", err)
+	}
+	dbconn, err := sql.Open("sqlite3", "file:"+*dbFilename+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("opening SQLite: %w", err)
+	}
+	defer func() {
+		_ = dbconn.Close()
+	}()
+
+	db, err := database.NewReadOnly(dbconn)
+	if err != nil {
+		return fmt.Errorf("creating AppDatabase: %w", err)
+	}
+
+	events, err := db.GetAuditEvents(filter)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tTIME\tACTOR\tACTION\tTARGET\tDETAILS\tREQUEST")
+	for _, e := range events {
+		actor := "-"
+		if e.ActorID != 0 {
+			actor = fmt.Sprint(e.ActorID)
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s %d\t%s\t%s\n", e.EventID, e.CreatedAt.Format(time.RFC3339), actor,
+			e.Action, e.TargetType, e.TargetID, e.Details, e.RequestID)
+	}
+	return w.Flush()
+}
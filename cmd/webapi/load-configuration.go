@@ -28,6 +28,45 @@ type WebAPIConfiguration struct {
 	DB    struct {
 		Filename string `conf:"default:/tmp/decaf.db"`
 	}
+	Comments struct {
+		BlockedWords    []string
+		BlockedPatterns []string
+	}
+	Admin struct {
+		Username string
+	}
+	Mail struct {
+		SMTPHost string
+		SMTPPort int `conf:"default:587"`
+		Username string
+		Password string `conf:"mask"`
+		From     string `conf:"default:noreply@localhost"`
+		File     string
+	}
+	Digests struct {
+		Interval time.Duration `conf:"default:1h"`
+	}
+	Feed struct {
+		RecencyWeight   float64       `conf:"default:1"`
+		RecencyHalfLife time.Duration `conf:"default:24h"`
+		LikesWeight     float64       `conf:"default:2"`
+		CommentsWeight  float64       `conf:"default:3"`
+		AffinityWeight  float64       `conf:"default:0.5"`
+		PopularWeight   float64       `conf:"default:0.5"`
+		CandidateWindow time.Duration `conf:"default:168h"`
+		MaxCandidates   int           `conf:"default:500"`
+	}
+	Explore struct {
+		Window          time.Duration `conf:"default:24h"`
+		RefreshInterval time.Duration `conf:"default:5m"`
+	}
+	Stream struct {
+		FanOut         bool `conf:"default:false"`
+		TimelineLength int  `conf:"default:1000"`
+	}
+	Events struct {
+		StreamDuration time.Duration `conf:"default:10m"`
+	}
 }
 
 // loadConfiguration creates a WebAPIConfiguration starting from flags, environment variables and configuration file.
@@ -37,6 +37,7 @@ import (
 	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/api"
 	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/database"
 	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/globaltime"
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/mail"
 	"github.com/ardanlabs/conf"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/sirupsen/logrus"
@@ -98,6 +99,43 @@ func run() error {
 		return fmt.Errorf("creating AppDatabase: %w", err)
 	}
 
+	// Designate the first admin of the platform, if requested
+	if cfg.Admin.Username != "" {
+		if !api.IsValidUsername(cfg.Admin.Username) {
+			logger.Errorf("invalid admin username %q", cfg.Admin.Username)
+			return fmt.Errorf("invalid admin username %q", cfg.Admin.Username)
+		}
+		admin, err := db.BootstrapAdmin(cfg.Admin.Username)
+		if err != nil {
+			logger.WithError(err).Error("error designating the admin")
+			return fmt.Errorf("designating the admin: %w", err)
+		}
+		logger.Infof("user %s (%d) is an admin", admin.Username, admin.UserID)
+	}
+
+	// Precompute the streams on write, if enabled
+	if cfg.Stream.FanOut {
+		if err := db.UseTimelines(cfg.Stream.TimelineLength); err != nil {
+			logger.WithError(err).Error("error building the timelines")
+			return fmt.Errorf("building the timelines: %w", err)
+		}
+		logger.Infof("streams are precomputed on write, up to %d photos each", cfg.Stream.TimelineLength)
+	}
+
+	// Start the email digests, if enabled
+	if cfg.Digests.Interval > 0 {
+		mailer, err := newMailer(cfg, logger)
+		if err != nil {
+			logger.WithError(err).Error("error creating the mailer")
+			return fmt.Errorf("creating the mailer: %w", err)
+		}
+		digests := mail.NewDigestJob(db, mailer, logger, cfg.Digests.Interval)
+		defer func() {
+			logger.Debug("digests stopping")
+			digests.Close()
+		}()
+	}
+
 	// Start (main) API server
 	logger.Info("initializing API server")
 
@@ -112,8 +150,23 @@ func run() error {
 
 	// Create the API router
 	apirouter, err := api.New(api.Config{
-		Logger:   logger,
-		Database: db,
+		Logger:                 logger,
+		Database:               db,
+		BlockedCommentWords:    cfg.Comments.BlockedWords,
+		BlockedCommentPatterns: cfg.Comments.BlockedPatterns,
+		EventStreamDuration:    cfg.Events.StreamDuration,
+		FeedWeights: database.FeedWeights{
+			Recency:         cfg.Feed.RecencyWeight,
+			RecencyHalfLife: cfg.Feed.RecencyHalfLife,
+			Likes:           cfg.Feed.LikesWeight,
+			Comments:        cfg.Feed.CommentsWeight,
+			Affinity:        cfg.Feed.AffinityWeight,
+			Popular:         cfg.Feed.PopularWeight,
+			CandidateWindow: cfg.Feed.CandidateWindow,
+			MaxCandidates:   cfg.Feed.MaxCandidates,
+		},
+		ExploreWindow:          cfg.Explore.Window,
+		ExploreRefreshInterval: cfg.Explore.RefreshInterval,
 	})
 	if err != nil {
 		logger.WithError(err).Error("error creating the API server instance")
@@ -183,3 +236,20 @@ func run() error {
 
 	return nil
 }
+
+// newMailer returns the Mailer selected in the configuration: the SMTP server if a host is set, otherwise the file if a
+// path is set, otherwise the log.
+func newMailer(cfg WebAPIConfiguration, logger *logrus.Logger) (mail.Mailer, error) {
+	switch {
+	case cfg.Mail.SMTPHost != "":
+		mailer, err := mail.NewSMTPMailer(cfg.Mail.SMTPHost, cfg.Mail.SMTPPort, cfg.Mail.Username, cfg.Mail.Password, cfg.Mail.From)
+		if err != nil {
+			return nil, err
+		}
+		return mailer, nil
+	case cfg.Mail.File != "":
+		return mail.NewFileMailer(cfg.Mail.File), nil
+	default:
+		return mail.NewLogMailer(logger), nil
+	}
+}
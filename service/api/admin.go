@@ -0,0 +1,224 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/api/reqcontext"
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/database"
+	"github.com/julienschmidt/httprouter"
+)
+
+// suspendUser suspends a user platform-wide: they can no longer log in, and their content is hidden.
+// Only admins can suspend users.
+func (rt *_router) suspendUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Authorization
+	adminID, authorizationStatus := rt.validateRole(extractBearer(r.Header.Get("Authorization")), database.RoleAdmin)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the ID of the user from the path.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("suspendUser: Invalid user ID format.")
+		return
+	}
+
+	// Check if the admin is trying to suspend themselves.
+	if userID == adminID {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.Error("suspendUser: Cannot suspend yourself.")
+		return
+	}
+
+	if err := rt.db.ForRequest(ctx.ReqUUID.String()).SetUserSuspended(adminID, userID, true); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The user does not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("suspendUser: User not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("suspendUser: Error updating suspension.")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// unsuspendUser lifts the suspension of a user. Only admins can lift suspensions.
+func (rt *_router) unsuspendUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Authorization
+	adminID, authorizationStatus := rt.validateRole(extractBearer(r.Header.Get("Authorization")), database.RoleAdmin)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the ID of the user from the path.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("unsuspendUser: Invalid user ID format.")
+		return
+	}
+
+	// Check if the admin is trying to unsuspend themselves.
+	if userID == adminID {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.Error("unsuspendUser: Cannot unsuspend yourself.")
+		return
+	}
+
+	if err := rt.db.ForRequest(ctx.ReqUUID.String()).SetUserSuspended(adminID, userID, false); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The user does not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("unsuspendUser: User not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("unsuspendUser: Error updating suspension.")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// setUserRole changes the role of a user. Only admins can change roles.
+func (rt *_router) setUserRole(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Authorization
+	adminID, authorizationStatus := rt.validateRole(extractBearer(r.Header.Get("Authorization")), database.RoleAdmin)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the ID of the user from the path.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("setUserRole: Invalid user ID format.")
+		return
+	}
+
+	// Admins cannot change their own role, so that the platform is never left without an admin by mistake.
+	if userID == adminID {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.Error("setUserRole: Cannot change your own role.")
+		return
+	}
+
+	// Extract the new role from the request body.
+	var body struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("setUserRole: Invalid request.")
+		return
+	}
+
+	if err := rt.db.ForRequest(ctx.ReqUUID.String()).SetUserRole(adminID, userID, body.Role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The user does not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("setUserRole: User not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("setUserRole: Error updating role.")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// getAuditEvents returns the audit log, filtered by actor, action, target and time range. Only admins can read it.
+func (rt *_router) getAuditEvents(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Authorization
+	_, authorizationStatus := rt.validateRole(extractBearer(r.Header.Get("Authorization")), database.RoleAdmin)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the filters from the query string.
+	var filter database.AuditFilter
+	var err error
+	query := r.URL.Query()
+	if value := query.Get("actorID"); value != "" {
+		if filter.ActorID, err = strconv.Atoi(value); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			ctx.Logger.WithError(err).Error("getAuditEvents: Invalid actor ID format.")
+			return
+		}
+	}
+	if value := query.Get("targetID"); value != "" {
+		if filter.TargetID, err = strconv.Atoi(value); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			ctx.Logger.WithError(err).Error("getAuditEvents: Invalid target ID format.")
+			return
+		}
+	}
+	if value := query.Get("since"); value != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, value); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			ctx.Logger.WithError(err).Error("getAuditEvents: Invalid since date.")
+			return
+		}
+	}
+	if value := query.Get("until"); value != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, value); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			ctx.Logger.WithError(err).Error("getAuditEvents: Invalid until date.")
+			return
+		}
+	}
+	filter.Action = query.Get("action")
+	filter.TargetType = query.Get("targetType")
+
+	// Extract the requested page.
+	filter.Limit, filter.Offset, err = getPagination(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getAuditEvents: Invalid pagination.")
+		return
+	}
+
+	dbEvents, err := rt.db.GetAuditEvents(filter)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getAuditEvents: Error fetching audit events.")
+		return
+	}
+
+	events := make([]AuditEvent, len(dbEvents))
+	for i, event := range dbEvents {
+		events[i].AuditEventFromDatabase(event)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(events)
+}
@@ -1,7 +1,10 @@
 package api
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/api/reqcontext"
 	"github.com/gofrs/uuid"
@@ -32,6 +35,22 @@ func (rt *_router) wrap(fn httpRouterHandler) func(http.ResponseWriter, *http.Re
 			"remote-ip": r.RemoteAddr,
 		})
 
+		// Suspended users cannot act on the platform, as they cannot log in: the requests carrying their token are
+		// refused.
+		if userID, err := strconv.Atoi(extractBearer(r.Header.Get("Authorization"))); err == nil {
+			suspended, err := rt.db.IsUserSuspended(userID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				ctx.Logger.WithError(err).Error("can't check the suspension of the requesting user")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if suspended {
+				ctx.Logger.Error("request refused: suspended account")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+
 		//          Call the next handler in chain (usually, the handler function for the path)
 		fn(w, r, ps, ctx)
 	}
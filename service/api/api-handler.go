@@ -12,21 +12,79 @@ func (rt *_router) Handler() http.Handler {
 	// User
 	rt.router.PUT("/users/:userid", rt.wrap(rt.setMyUserName))
 	rt.router.GET("/users/:userid", rt.wrap(rt.getUserProfile))
+	rt.router.PATCH("/users/:userid", rt.wrap(rt.updateProfile))
+	rt.router.GET("/users/:userid/mutual-followers", rt.wrap(rt.getMutualFollowers))
 	rt.router.POST("/users/:userid/following", rt.wrap(rt.followUser))
 	rt.router.DELETE("/users/:userid/following/:followingid", rt.wrap(rt.unfollowUser))
+	rt.router.GET("/users/:userid/follow-requests", rt.wrap(rt.getFollowRequests))
+	rt.router.PUT("/users/:userid/follow-requests/:requesterid", rt.wrap(rt.approveFollowRequest))
+	rt.router.DELETE("/users/:userid/follow-requests/:requesterid", rt.wrap(rt.rejectFollowRequest))
 	rt.router.POST("/users/:userid/banned-users", rt.wrap(rt.banUser))
+	rt.router.GET("/users/:userid/banned-users", rt.wrap(rt.getBannedUsers))
 	rt.router.DELETE("/users/:userid/banned-users/:banneduserid", rt.wrap(rt.unbanUser))
 	rt.router.GET("/users/:userid/banned-users/:banneduserid", rt.wrap(rt.getBanStatus))
+	rt.router.POST("/users/:userid/muted-users", rt.wrap(rt.muteUser))
+	rt.router.GET("/users/:userid/muted-users", rt.wrap(rt.getMutedUsers))
+	rt.router.DELETE("/users/:userid/muted-users/:muteduserid", rt.wrap(rt.unmuteUser))
+	rt.router.POST("/users/:userid/reported-users", rt.wrap(rt.reportUser))
 	rt.router.GET("/users/:userid/stream", rt.wrap(rt.getMyStream))
+	rt.router.GET("/users/:userid/stream/seen", rt.wrap(rt.getStreamMarker))
+	rt.router.PUT("/users/:userid/stream/seen", rt.wrap(rt.setStreamSeen))
+	rt.router.GET("/users/:userid/suggestions", rt.wrap(rt.getSuggestions))
+	rt.router.GET("/users/:userid/relationships", rt.wrap(rt.getRelationships))
+	rt.router.GET("/users/:userid/relationships/:otherid", rt.wrap(rt.getRelationship))
 	rt.router.GET("/users", rt.wrap(rt.getUsers))
 
+	// Notifications
+	rt.router.GET("/users/:userid/notifications", rt.wrap(rt.getNotifications))
+	rt.router.PATCH("/users/:userid/notifications", rt.wrap(rt.markAllNotificationsRead))
+	rt.router.GET("/users/:userid/notifications/unread-count", rt.wrap(rt.getUnreadNotificationsCount))
+	rt.router.PATCH("/users/:userid/notifications/:notificationid", rt.wrap(rt.setNotificationRead))
+	rt.router.GET("/users/:userid/settings/notifications", rt.wrap(rt.getNotificationPreferences))
+	rt.router.PUT("/users/:userid/settings/notifications", rt.wrap(rt.setNotificationPreferences))
+	rt.router.GET("/users/:userid/events", rt.wrap(rt.getEvents))
+
+	// Webhooks
+	rt.router.POST("/users/:userid/webhooks", rt.wrap(rt.createWebhook))
+	rt.router.GET("/users/:userid/webhooks", rt.wrap(rt.getWebhooks))
+	rt.router.DELETE("/users/:userid/webhooks/:webhookid", rt.wrap(rt.deleteWebhook))
+	rt.router.GET("/users/:userid/webhooks/:webhookid/deliveries", rt.wrap(rt.getWebhookDeliveries))
+
+	// Collections
+	rt.router.POST("/users/:userid/collections", rt.wrap(rt.createCollection))
+	rt.router.GET("/users/:userid/collections", rt.wrap(rt.getCollections))
+	rt.router.PATCH("/users/:userid/collections/:collectionid", rt.wrap(rt.renameCollection))
+	rt.router.DELETE("/users/:userid/collections/:collectionid", rt.wrap(rt.deleteCollection))
+	rt.router.POST("/users/:userid/collections/:collectionid/photos", rt.wrap(rt.addToCollection))
+	rt.router.GET("/users/:userid/collections/:collectionid/photos", rt.wrap(rt.getCollectionPhotos))
+	rt.router.DELETE("/users/:userid/collections/:collectionid/photos/:photoid", rt.wrap(rt.removeFromCollection))
+
 	// Photo
 	rt.router.POST("/users/:userid/photos", rt.wrap(rt.uploadPhoto))
 	rt.router.POST("/users/:userid/photos/:photoid/likes", rt.wrap(rt.likePhoto))
+	rt.router.GET("/users/:userid/photos/:photoid/likes", rt.wrap(rt.getPhotoLikes))
 	rt.router.DELETE("/users/:userid/photos/:photoid/likes/:likeid", rt.wrap(rt.unlikePhoto))
 	rt.router.POST("/users/:userid/photos/:photoid/comments", rt.wrap(rt.commentPhoto))
+	rt.router.GET("/users/:userid/photos/:photoid/comments", rt.wrap(rt.getPhotoComments))
 	rt.router.DELETE("/users/:userid/photos/:photoid/comments/:commentid", rt.wrap(rt.uncommentPhoto))
+	rt.router.PATCH("/users/:userid/photos/:photoid/comments/:commentid", rt.wrap(rt.editComment))
+	rt.router.GET("/users/:userid/photos/:photoid/comments/:commentid/revisions", rt.wrap(rt.getCommentRevisions))
+	rt.router.PUT("/users/:userid/photos/:photoid/comments/:commentid/hidden", rt.wrap(rt.hideComment))
+	rt.router.DELETE("/users/:userid/photos/:photoid/comments/:commentid/hidden", rt.wrap(rt.unhideComment))
+	rt.router.POST("/users/:userid/photos/:photoid/comments/:commentid/reports", rt.wrap(rt.reportComment))
+	rt.router.POST("/users/:userid/photos/:photoid/reports", rt.wrap(rt.reportPhoto))
+	rt.router.GET("/users/:userid/photos/:photoid", rt.wrap(rt.getPhoto))
 	rt.router.DELETE("/users/:userid/photos/:photoid", rt.wrap(rt.deletePhoto))
+	rt.router.PATCH("/users/:userid/photos/:photoid", rt.wrap(rt.updatePhoto))
+	rt.router.GET("/explore", rt.wrap(rt.getExplore))
+
+	// Admin
+	rt.router.GET("/admin/reports", rt.wrap(rt.getReports))
+	rt.router.PUT("/admin/reports/:reportid/decision", rt.wrap(rt.decideReport))
+	rt.router.PUT("/admin/users/:userid/suspension", rt.wrap(rt.suspendUser))
+	rt.router.DELETE("/admin/users/:userid/suspension", rt.wrap(rt.unsuspendUser))
+	rt.router.PUT("/admin/users/:userid/role", rt.wrap(rt.setUserRole))
+	rt.router.GET("/admin/audit-events", rt.wrap(rt.getAuditEvents))
 
 	// Special routes
 	rt.router.GET("/liveness", rt.liveness)
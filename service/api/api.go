@@ -11,6 +11,18 @@ Example:
 	apirouter, err := api.New(api.Config{
 		Logger:   logger,
 		Database: appdb,
+		FeedWeights: database.FeedWeights{
+			Recency:         1,
+			RecencyHalfLife: 24 * time.Hour,
+			Likes:           2,
+			Comments:        3,
+			Affinity:        0.5,
+			Popular:         0.5,
+			CandidateWindow: 7 * 24 * time.Hour,
+			MaxCandidates:   500,
+		},
+		ExploreWindow:          24 * time.Hour,
+		ExploreRefreshInterval: 5 * time.Minute,
 	})
 	if err != nil {
 		logger.WithError(err).Error("error creating the API server instance")
@@ -39,8 +51,10 @@ package api
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/database"
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/webhooks"
 	"github.com/julienschmidt/httprouter"
 	"github.com/sirupsen/logrus"
 )
@@ -52,6 +66,25 @@ type Config struct {
 
 	// Database is the instance of database.AppDatabase where data are saved
 	Database database.AppDatabase
+
+	// BlockedCommentWords are words that cannot appear in comments (whole words, case-insensitive)
+	BlockedCommentWords []string
+
+	// BlockedCommentPatterns are regular expressions that comments cannot match
+	BlockedCommentPatterns []string
+
+	// EventStreamDuration is the maximum duration of an event stream, after which clients reconnect. Event streams are
+	// not subject to the write timeout of the HTTP server. Zero means no limit.
+	EventStreamDuration time.Duration
+
+	// FeedWeights are the weights used to score the photos of the ranked stream
+	FeedWeights database.FeedWeights
+
+	// ExploreWindow is the period over which the activity of the trending photos is measured
+	ExploreWindow time.Duration
+
+	// ExploreRefreshInterval is how often the trending photos are recomputed
+	ExploreRefreshInterval time.Duration
 }
 
 // Router is the package API interface representing an API handler builder
@@ -73,6 +106,19 @@ func New(cfg Config) (Router, error) {
 		return nil, errors.New("database is required")
 	}
 
+	if cfg.FeedWeights.RecencyHalfLife <= 0 || cfg.FeedWeights.CandidateWindow <= 0 || cfg.FeedWeights.MaxCandidates <= 0 {
+		return nil, errors.New("feed recency half-life, candidate window and maximum candidates must be positive")
+	}
+
+	if cfg.ExploreWindow <= 0 || cfg.ExploreRefreshInterval <= 0 {
+		return nil, errors.New("explore window and refresh interval must be positive")
+	}
+
+	filter, err := newCommentFilter(cfg.BlockedCommentWords, cfg.BlockedCommentPatterns)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create a new router where we will register HTTP endpoints. The server will pass requests to this router to be
 	// handled.
 	router := httprouter.New()
@@ -80,9 +126,15 @@ func New(cfg Config) (Router, error) {
 	router.RedirectFixedPath = false
 
 	return &_router{
-		router:     router,
-		baseLogger: cfg.Logger,
-		db:         cfg.Database,
+		router:              router,
+		baseLogger:          cfg.Logger,
+		db:                  cfg.Database,
+		commentFilter:       filter,
+		events:              newEventHub(),
+		eventStreamDuration: cfg.EventStreamDuration,
+		feedWeights:         cfg.FeedWeights,
+		trending:            newTrendingCache(cfg.Database, cfg.Logger, cfg.ExploreWindow, cfg.ExploreRefreshInterval),
+		webhooks:            webhooks.NewWorker(cfg.Database, cfg.Logger),
 	}, nil
 }
 
@@ -94,4 +146,22 @@ type _router struct {
 	baseLogger logrus.FieldLogger
 
 	db database.AppDatabase
+
+	// commentFilter validates the text of new and edited comments.
+	commentFilter commentFilter
+
+	// events delivers real-time events to the connected users.
+	events *eventHub
+
+	// eventStreamDuration is the maximum duration of an event stream.
+	eventStreamDuration time.Duration
+
+	// feedWeights are the weights used to score the photos of the ranked stream.
+	feedWeights database.FeedWeights
+
+	// trending keeps the photos shown in the explore page.
+	trending *trendingCache
+
+	// webhooks delivers the queued events to the registered webhooks in the background.
+	webhooks *webhooks.Worker
 }
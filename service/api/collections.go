@@ -0,0 +1,362 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/api/reqcontext"
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/database"
+	"github.com/julienschmidt/httprouter"
+)
+
+// createCollection creates a collection of the specified user, to privately save photos to.
+func (rt *_router) createCollection(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("createCollection: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the name from the request body.
+	collection, err := readCollection(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("createCollection: Invalid request.")
+		return
+	}
+	collection.OwnerID = userID
+
+	dbCollection, err := rt.db.CreateCollection(collection.CollectionToDatabase())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("createCollection: Error creating collection.")
+		return
+	}
+
+	collection.CollectionFromDatabase(dbCollection)
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(collection)
+}
+
+// getCollections returns the collections of the specified user. Collections are private, so only the owner can list
+// them.
+func (rt *_router) getCollections(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getCollections: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	dbCollections, err := rt.db.GetCollections(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getCollections: Error fetching collections.")
+		return
+	}
+
+	collections := make([]Collection, len(dbCollections))
+	for i, collection := range dbCollections {
+		collections[i].CollectionFromDatabase(collection)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(collections)
+}
+
+// renameCollection renames a collection of the specified user.
+func (rt *_router) renameCollection(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("renameCollection: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the collection ID from the path parameters.
+	collectionID, err := strconv.Atoi(ps.ByName("collectionid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("renameCollection: Invalid collection ID format.")
+		return
+	}
+
+	// Extract the new name from the request body.
+	collection, err := readCollection(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("renameCollection: Invalid request.")
+		return
+	}
+
+	err = rt.db.RenameCollection(userID, collectionID, collection.Name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The collection does not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("renameCollection: Collection not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("renameCollection: Error renaming collection.")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteCollection removes a collection of the specified user. The saved photos are not affected.
+func (rt *_router) deleteCollection(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("deleteCollection: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the collection ID from the path parameters.
+	collectionID, err := strconv.Atoi(ps.ByName("collectionid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("deleteCollection: Invalid collection ID format.")
+		return
+	}
+
+	err = rt.db.DeleteCollection(userID, collectionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The collection does not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("deleteCollection: Collection not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("deleteCollection: Error removing collection.")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// addToCollection saves a photo to a collection of the specified user. Saving a photo twice has no effect.
+func (rt *_router) addToCollection(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("addToCollection: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the collection ID from the path parameters.
+	collectionID, err := strconv.Atoi(ps.ByName("collectionid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("addToCollection: Invalid collection ID format.")
+		return
+	}
+
+	// Extract the photo to save from the request body.
+	var body struct {
+		PhotoID int `json:"photoID"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("addToCollection: Invalid request.")
+		return
+	}
+	if body.PhotoID <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.Error("addToCollection: Invalid photo ID.")
+		return
+	}
+
+	err = rt.db.AddToCollection(userID, collectionID, body.PhotoID, time.Now())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, database.ErrBannedByUser) {
+			// The collection or the photo does not exist, or the author banned the user: return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("addToCollection: Collection or photo not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("addToCollection: Error saving photo.")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeFromCollection removes a photo from a collection of the specified user.
+func (rt *_router) removeFromCollection(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("removeFromCollection: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the collection and photo IDs from the path parameters.
+	collectionID, err := strconv.Atoi(ps.ByName("collectionid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("removeFromCollection: Invalid collection ID format.")
+		return
+	}
+	photoID, err := strconv.Atoi(ps.ByName("photoid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("removeFromCollection: Invalid photo ID format.")
+		return
+	}
+
+	err = rt.db.RemoveFromCollection(userID, collectionID, photoID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The collection does not exist, or the photo is not in it: return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("removeFromCollection: Collection or photo not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("removeFromCollection: Error removing photo.")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getCollectionPhotos returns a page of the photos of a collection of the specified user, most recently saved first.
+// Photos deleted since, or whose author banned the user, are left out.
+func (rt *_router) getCollectionPhotos(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getCollectionPhotos: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the collection ID from the path parameters.
+	collectionID, err := strconv.Atoi(ps.ByName("collectionid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getCollectionPhotos: Invalid collection ID format.")
+		return
+	}
+
+	// Extract the requested page.
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getCollectionPhotos: Invalid pagination.")
+		return
+	}
+
+	photos, err := rt.db.GetCollectionPhotos(userID, collectionID, limit, offset)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The collection does not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("getCollectionPhotos: Collection not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getCollectionPhotos: Error fetching photos.")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(photos)
+}
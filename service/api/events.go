@@ -0,0 +1,336 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/api/reqcontext"
+	"github.com/julienschmidt/httprouter"
+)
+
+// Types of the events pushed to the users.
+const (
+	eventLike          = "like"           // Someone liked one of the user's photos
+	eventComment       = "comment"        // Someone commented one of the user's photos
+	eventFollow        = "follow"         // Someone started following the user
+	eventFollowRequest = "follow-request" // Someone asked to follow the user
+	eventPhoto         = "photo"          // Someone followed by the user uploaded a photo
+)
+
+const (
+	// eventBufferSize is the number of events queued for each connection. Connections that fall further behind are
+	// closed, and the client resumes from the last event it received.
+	eventBufferSize = 64
+
+	// eventHistorySize is the number of recent events kept for each user, to resume interrupted streams.
+	eventHistorySize = 100
+
+	// eventHistoryTTL is how long the events are kept for resuming streams.
+	eventHistoryTTL = 5 * time.Minute
+
+	// eventHeartbeatInterval is how often a comment is sent on idle streams, so that proxies keep them open.
+	eventHeartbeatInterval = 15 * time.Second
+)
+
+// event is a message pushed to a user. IDs are increasing across all users.
+type event struct {
+	ID          uint64
+	Type        string
+	Data        []byte
+	publishedAt time.Time
+}
+
+// eventData is the JSON payload of an event.
+type eventData struct {
+	ActorID   int `json:"actorID"`
+	PhotoID   int `json:"photoID,omitempty"`
+	CommentID int `json:"commentID,omitempty"`
+}
+
+// eventSubscriber is a connection waiting for the events of a user.
+type eventSubscriber struct {
+	events chan event
+}
+
+// eventHub is an in-process publish/subscribe hub delivering events to the connected users.
+type eventHub struct {
+	mu          sync.Mutex
+	lastID      uint64
+	history     map[int][]event
+	lastSweep   time.Time
+	subscribers map[int]map[*eventSubscriber]struct{}
+	closed      bool
+}
+
+// newEventHub returns an empty eventHub.
+func newEventHub() *eventHub {
+	return &eventHub{
+		history:     make(map[int][]event),
+		subscribers: make(map[int]map[*eventSubscriber]struct{}),
+	}
+}
+
+// publish sends an event to every connection of the user, and keeps it for resuming streams. Events are not blocked
+// by slow connections: those are closed instead.
+func (h *eventHub) publish(userID int, eventType string, data eventData) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+
+	now := time.Now()
+	h.sweep(now)
+
+	h.lastID++
+	ev := event{ID: h.lastID, Type: eventType, Data: payload, publishedAt: now}
+
+	history := append(expireEvents(h.history[userID], now), ev)
+	if len(history) > eventHistorySize {
+		history = history[len(history)-eventHistorySize:]
+	}
+	h.history[userID] = history
+
+	for sub := range h.subscribers[userID] {
+		select {
+		case sub.events <- ev:
+		default:
+			// The connection is too slow: drop it.
+			delete(h.subscribers[userID], sub)
+			close(sub.events)
+		}
+	}
+}
+
+// sweep drops the expired events of every user, at most once every eventHistoryTTL, so that the history of the users
+// who receive no new events does not grow stale.
+func (h *eventHub) sweep(now time.Time) {
+	if now.Sub(h.lastSweep) < eventHistoryTTL {
+		return
+	}
+	h.lastSweep = now
+
+	for userID, history := range h.history {
+		if history = expireEvents(history, now); len(history) > 0 {
+			h.history[userID] = history
+		} else {
+			delete(h.history, userID)
+		}
+	}
+}
+
+// expireEvents returns the events of a history that are not older than eventHistoryTTL.
+func expireEvents(history []event, now time.Time) []event {
+	for len(history) > 0 && now.Sub(history[0].publishedAt) > eventHistoryTTL {
+		history = history[1:]
+	}
+	return history
+}
+
+// subscribe registers a new connection for the user, and returns the kept events following lastEventID.
+// It returns nil if the hub has been closed.
+func (h *eventHub) subscribe(userID int, lastEventID uint64) (*eventSubscriber, []event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, nil
+	}
+
+	var backlog []event
+	for _, ev := range expireEvents(h.history[userID], time.Now()) {
+		if ev.ID > lastEventID {
+			backlog = append(backlog, ev)
+		}
+	}
+
+	sub := &eventSubscriber{events: make(chan event, eventBufferSize)}
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*eventSubscriber]struct{})
+	}
+	h.subscribers[userID][sub] = struct{}{}
+	return sub, backlog
+}
+
+// unsubscribe removes a connection of the user, unless it has already been dropped.
+func (h *eventHub) unsubscribe(userID int, sub *eventSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[userID][sub]; ok {
+		delete(h.subscribers[userID], sub)
+		close(sub.events)
+	}
+	if len(h.subscribers[userID]) == 0 {
+		delete(h.subscribers, userID)
+	}
+}
+
+// close ends every connection, and rejects new ones.
+func (h *eventHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for userID, subs := range h.subscribers {
+		for sub := range subs {
+			close(sub.events)
+		}
+		delete(h.subscribers, userID)
+	}
+}
+
+// publish sends an event to the user, unless their notification preferences exclude pushing events of its type. The
+// types of the events match the types of the notifications.
+func (rt *_router) publish(userID int, eventType string, data eventData, ctx reqcontext.RequestContext) {
+	channels, err := rt.db.GetNotificationChannels(userID, eventType)
+	if err != nil {
+		ctx.Logger.WithError(err).Warning("cannot publish event: error getting the notification preferences")
+		return
+	}
+	if channels.Push {
+		rt.events.publish(userID, eventType, data)
+	}
+}
+
+// publishUnlessMuted sends an event to the user, unless they muted the actor.
+func (rt *_router) publishUnlessMuted(userID int, eventType string, data eventData, ctx reqcontext.RequestContext) {
+	muted, err := rt.db.HasMuted(userID, data.ActorID)
+	if err != nil {
+		ctx.Logger.WithError(err).Warning("cannot publish event: error checking the mute status")
+		return
+	}
+	if !muted {
+		rt.publish(userID, eventType, data, ctx)
+	}
+}
+
+// publishToPhotoOwner sends an event about a photo to its owner, unless they are the actor or they muted the actor.
+func (rt *_router) publishToPhotoOwner(photoID int, eventType string, data eventData, ctx reqcontext.RequestContext) {
+	ownerID, err := rt.db.GetPhotoUserID(photoID)
+	if err != nil {
+		ctx.Logger.WithError(err).Warning("cannot publish event: error getting the photo owner")
+		return
+	}
+	if ownerID != data.ActorID {
+		rt.publishUnlessMuted(ownerID, eventType, data, ctx)
+	}
+}
+
+// publishToFollowers sends an event to every follower of the user who did not mute them.
+func (rt *_router) publishToFollowers(userID int, eventType string, data eventData, ctx reqcontext.RequestContext) {
+	followers, err := rt.db.GetUnmutedFollowers(userID)
+	if err != nil {
+		ctx.Logger.WithError(err).Warning("cannot publish event: error getting the followers")
+		return
+	}
+	for _, follower := range followers {
+		rt.publish(follower.UserID, eventType, data, ctx)
+	}
+}
+
+// getEvents streams the events of the specified user with Server-Sent Events. Interrupted streams are resumed from
+// the ID in the Last-Event-ID header. Streams are not subject to the server write timeout, and are closed after
+// eventStreamDuration instead; clients reconnect and resume.
+func (rt *_router) getEvents(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getEvents: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the last event received by the client, if any.
+	var lastEventID uint64
+	if value := r.Header.Get("Last-Event-ID"); value != "" {
+		lastEventID, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			ctx.Logger.WithError(err).Error("getEvents: Invalid Last-Event-ID.")
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.Error("getEvents: Streaming not supported.")
+		return
+	}
+
+	// Lift the write timeout of the server, which would cut the stream.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.WithError(err).Error("getEvents: Cannot lift the write deadline.")
+		return
+	}
+
+	sub, backlog := rt.events.subscribe(userID, lastEventID)
+	if sub == nil {
+		// The server is shutting down.
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	defer rt.events.unsubscribe(userID, sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, ev := range backlog {
+		writeEvent(w, ev)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	var deadline <-chan time.Time
+	if rt.eventStreamDuration > 0 {
+		timer := time.NewTimer(rt.eventStreamDuration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	for {
+		select {
+		case ev, ok := <-sub.events:
+			if !ok {
+				// The connection has been dropped, or the server is shutting down.
+				return
+			}
+			writeEvent(w, ev)
+			flusher.Flush()
+		case <-heartbeat.C:
+			_, _ = fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case <-deadline:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeEvent writes an event in the Server-Sent Events format.
+func writeEvent(w http.ResponseWriter, ev event) {
+	_, _ = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, ev.Data)
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/api/reqcontext"
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/database"
+	"github.com/julienschmidt/httprouter"
+	"github.com/sirupsen/logrus"
+)
+
+// maxTrendingPhotos is the number of trending photos kept in the cache.
+const maxTrendingPhotos = 500
+
+// trendingCache keeps the trending photos, recomputed periodically in the background, so that the explore page does
+// not score every photo on each request.
+type trendingCache struct {
+	db     database.AppDatabase
+	logger logrus.FieldLogger
+	window time.Duration
+
+	mu     sync.RWMutex
+	photos []database.TrendingPhoto
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newTrendingCache computes the trending photos over the specified window, and starts recomputing them at the
+// specified interval.
+func newTrendingCache(db database.AppDatabase, logger logrus.FieldLogger, window, interval time.Duration) *trendingCache {
+	c := &trendingCache{
+		db:     db,
+		logger: logger,
+		window: window,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	c.refresh()
+	go c.run(interval)
+	return c
+}
+
+// run recomputes the trending photos periodically, until the cache is closed.
+func (c *trendingCache) run(interval time.Duration) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.refresh()
+		}
+	}
+}
+
+// refresh recomputes the trending photos. On errors, the previous ones are kept.
+func (c *trendingCache) refresh() {
+	photos, err := c.db.GetTrendingPhotos(c.window, maxTrendingPhotos)
+	if err != nil {
+		c.logger.WithError(err).Error("explore: error computing trending photos")
+		return
+	}
+
+	c.mu.Lock()
+	c.photos = photos
+	c.mu.Unlock()
+}
+
+// get returns the trending photos, best first. The slice must not be modified.
+func (c *trendingCache) get() []database.TrendingPhoto {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.photos
+}
+
+// close stops recomputing the trending photos.
+func (c *trendingCache) close() {
+	close(c.stop)
+	<-c.done
+}
+
+// getExplore returns a page of the trending photos the user making the request can see. Photos of users who banned
+// the viewer, or were banned by them, and photos deleted or made private since the last computation are left out.
+func (rt *_router) getExplore(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	if !isUserLoggedIn(bearerToken) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(bearerToken)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		ctx.Logger.WithError(err).Error("getExplore: Invalid user ID.")
+		return
+	}
+
+	// Extract the requested page.
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getExplore: Invalid pagination.")
+		return
+	}
+
+	// Filter the trending photos for the viewer, and load the requested page only.
+	photos, err := rt.db.GetExplorePhotos(userID, rt.trending.get(), limit, offset)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getExplore: Error fetching photos.")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(photos)
+}
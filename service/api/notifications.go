@@ -0,0 +1,293 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/api/reqcontext"
+	"github.com/julienschmidt/httprouter"
+)
+
+// getNotifications returns the notifications of the specified user, most recent first.
+func (rt *_router) getNotifications(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getNotifications: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the requested page.
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getNotifications: Invalid pagination.")
+		return
+	}
+
+	dbNotifications, err := rt.db.GetNotifications(userID, limit, offset)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getNotifications: Error fetching notifications.")
+		return
+	}
+
+	notifications := make([]Notification, len(dbNotifications))
+	for i, notification := range dbNotifications {
+		notifications[i].NotificationFromDatabase(notification)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(notifications)
+}
+
+// getUnreadNotificationsCount returns the number of unread notifications of the specified user.
+func (rt *_router) getUnreadNotificationsCount(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getUnreadNotificationsCount: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	count, err := rt.db.GetUnreadNotificationsCount(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getUnreadNotificationsCount: Error counting notifications.")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(struct {
+		Count int `json:"count"`
+	}{count})
+}
+
+// setNotificationRead marks a notification, with the ones aggregated with it, as read or unread.
+func (rt *_router) setNotificationRead(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("setNotificationRead: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the notification ID from the path parameters.
+	notificationID, err := strconv.Atoi(ps.ByName("notificationid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("setNotificationRead: Invalid notification ID format.")
+		return
+	}
+
+	// Extract the new state from the request body.
+	var state struct {
+		Read *bool `json:"read"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&state); err != nil || state.Read == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("setNotificationRead: Invalid request.")
+		return
+	}
+
+	if err := rt.db.SetNotificationRead(userID, notificationID, *state.Read); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The notification does not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("setNotificationRead: Notification not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("setNotificationRead: Error updating notification.")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// markAllNotificationsRead marks every notification of the specified user as read.
+func (rt *_router) markAllNotificationsRead(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("markAllNotificationsRead: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Only marking every notification as read is supported.
+	var state struct {
+		Read *bool `json:"read"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&state); err != nil || state.Read == nil || !*state.Read {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("markAllNotificationsRead: Invalid request.")
+		return
+	}
+
+	if err := rt.db.MarkAllNotificationsRead(userID); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("markAllNotificationsRead: Error updating notifications.")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// getNotificationPreferences returns which events notify the specified user, and through which channels.
+func (rt *_router) getNotificationPreferences(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getNotificationPreferences: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	dbPreferences, err := rt.db.GetNotificationPreferences(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The user does not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("getNotificationPreferences: User not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getNotificationPreferences: Error fetching preferences.")
+		return
+	}
+
+	var preferences NotificationPreferences
+	preferences.NotificationPreferencesFromDatabase(dbPreferences)
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(preferences)
+}
+
+// setNotificationPreferences replaces which events notify the specified user, and through which channels. Kinds of
+// events missing from the request are reset to the defaults.
+func (rt *_router) setNotificationPreferences(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("setNotificationPreferences: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the new preferences from the request body.
+	var preferences NotificationPreferences
+	if err := json.NewDecoder(r.Body).Decode(&preferences); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("setNotificationPreferences: Invalid request.")
+		return
+	}
+	for event := range preferences.Events {
+		if !notificationPreferenceEvents[event] {
+			w.WriteHeader(http.StatusBadRequest)
+			ctx.Logger.Errorf("setNotificationPreferences: Invalid event %q.", event)
+			return
+		}
+	}
+
+	// A pause in the past is the same as no pause.
+	if preferences.PausedUntil != nil && !preferences.PausedUntil.After(time.Now()) {
+		preferences.PausedUntil = nil
+	}
+
+	if err := rt.db.SetNotificationPreferences(userID, preferences.NotificationPreferencesToDatabase()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The user does not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("setNotificationPreferences: User not found.")
+			return
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("setNotificationPreferences: Error updating preferences.")
+		return
+	}
+
+	// Return the resulting preferences, with the defaults filled in.
+	dbPreferences, err := rt.db.GetNotificationPreferences(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("setNotificationPreferences: Error fetching preferences.")
+		return
+	}
+	preferences.NotificationPreferencesFromDatabase(dbPreferences)
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(preferences)
+}
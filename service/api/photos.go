@@ -63,6 +63,7 @@ func (rt *_router) uploadPhoto(w http.ResponseWriter, r *http.Request, ps httpro
 	photo.UploadDate = time.Now()
 	photo.LikesCount = 0
 	photo.CommentsCount = 0
+	photo.CommentsEnabled = true
 
 	// Create the photo in the database
 	createdPhoto, err := rt.db.CreatePhoto(photo.PhotoToDatabase())
@@ -73,6 +74,10 @@ func (rt *_router) uploadPhoto(w http.ResponseWriter, r *http.Request, ps httpro
 	}
 
 	photo.PhotoFromDatabase(createdPhoto)
+
+	// Tell the followers about the new photo.
+	rt.publishToFollowers(userID, eventPhoto, eventData{ActorID: userID, PhotoID: photo.PhotoID}, ctx)
+
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(photo)
 }
@@ -121,6 +126,9 @@ func (rt *_router) likePhoto(w http.ResponseWriter, r *http.Request, ps httprout
 		return
 	}
 
+	// Tell the owner of the photo about the like.
+	rt.publishToPhotoOwner(photoID, eventLike, eventData{ActorID: userID, PhotoID: photoID}, ctx)
+
 	w.WriteHeader(http.StatusCreated)
 }
 
@@ -206,13 +214,18 @@ func (rt *_router) commentPhoto(w http.ResponseWriter, r *http.Request, ps httpr
 		return
 	}
 
-	var comment Comment
 	// Extract the comment from the request body.
-	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+	comment, reason, err := rt.readComment(r)
+	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		ctx.Logger.WithError(err).Error("commentPhoto: Error decoding request body.")
 		return
 	}
+	if reason != "" {
+		ctx.Logger.WithField("reason", reason).Info("commentPhoto: Comment rejected.")
+		rejectComment(w, reason)
+		return
+	}
 
 	// Get the username of the user.
 	user, err := rt.db.GetUserDetails(userID)
@@ -235,11 +248,14 @@ func (rt *_router) commentPhoto(w http.ResponseWriter, r *http.Request, ps httpr
 	// Update the user data with the information from the database.
 	comment.CommentFromDatabase(newComment)
 
+	// Tell the owner of the photo about the comment.
+	rt.publishToPhotoOwner(photoID, eventComment, eventData{ActorID: userID, PhotoID: photoID, CommentID: comment.CommentID}, ctx)
+
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(comment)
 }
 
-// UncommentPhoto removes a comment from a photo.
+// UncommentPhoto removes a comment from a photo. Both the author of the comment and the owner of the photo can remove it.
 func (rt *_router) uncommentPhoto(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -276,7 +292,7 @@ func (rt *_router) uncommentPhoto(w http.ResponseWriter, r *http.Request, ps htt
 	}
 
 	// Uncomment photo.
-	if err := rt.db.UncommentPhoto(userID, photoID, commentID); err != nil {
+	if err := rt.db.ForRequest(ctx.ReqUUID.String()).UncommentPhoto(userID, photoID, commentID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			// If any of the input IDs do not exist, return a NotFound status.
 			w.WriteHeader(http.StatusNotFound)
@@ -293,6 +309,496 @@ func (rt *_router) uncommentPhoto(w http.ResponseWriter, r *http.Request, ps htt
 	w.WriteHeader(http.StatusOK)
 }
 
+// editComment replaces the text of a comment, keeping the previous text in its edit history.
+func (rt *_router) editComment(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the user ID from the path parameters.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("editComment: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the photo ID from the path parameters.
+	photoID, err := strconv.Atoi(ps.ByName("photoid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("editComment: Invalid photo ID format.")
+		return
+	}
+
+	// Extract the comment ID from the path parameters.
+	commentID, err := strconv.Atoi(ps.ByName("commentid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("editComment: Invalid comment ID format.")
+		return
+	}
+
+	// Extract the new comment text from the request body.
+	comment, reason, err := rt.readComment(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("editComment: Error decoding request body.")
+		return
+	}
+	if reason != "" {
+		ctx.Logger.WithField("reason", reason).Info("editComment: Comment rejected.")
+		rejectComment(w, reason)
+		return
+	}
+
+	editedAt := time.Now()
+	comment.EditedAt = &editedAt
+
+	// Edit the comment.
+	editedComment, err := rt.db.EditComment(userID, photoID, commentID, comment.CommentToDatabase())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// If any of the input IDs do not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("editComment: Not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("editComment: Error editing comment.")
+		return
+	}
+
+	comment.CommentFromDatabase(editedComment)
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(comment)
+}
+
+// getCommentRevisions returns the edit history of a comment to the owner of the photo.
+func (rt *_router) getCommentRevisions(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the user ID from the path parameters.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getCommentRevisions: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the photo ID from the path parameters.
+	photoID, err := strconv.Atoi(ps.ByName("photoid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getCommentRevisions: Invalid photo ID format.")
+		return
+	}
+
+	// Extract the comment ID from the path parameters.
+	commentID, err := strconv.Atoi(ps.ByName("commentid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getCommentRevisions: Invalid comment ID format.")
+		return
+	}
+
+	// Get the edit history of the comment.
+	dbRevisions, err := rt.db.GetCommentRevisions(userID, photoID, commentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// If any of the input IDs do not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("getCommentRevisions: Not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getCommentRevisions: Error getting comment revisions.")
+		return
+	}
+
+	revisions := make([]CommentRevision, len(dbRevisions))
+	for i, revision := range dbRevisions {
+		revisions[i].CommentRevisionFromDatabase(revision)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(revisions)
+}
+
+// hideComment hides a comment pending review. Only the owner of the photo can hide its comments.
+func (rt *_router) hideComment(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the user ID from the path parameters.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("hideComment: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the photo ID from the path parameters.
+	photoID, err := strconv.Atoi(ps.ByName("photoid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("hideComment: Invalid photo ID format.")
+		return
+	}
+
+	// Extract the comment ID from the path parameters.
+	commentID, err := strconv.Atoi(ps.ByName("commentid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("hideComment: Invalid comment ID format.")
+		return
+	}
+
+	// Update the comment visibility.
+	if err := rt.db.SetCommentHidden(userID, photoID, commentID, true); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// If any of the input IDs do not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("hideComment: Not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("hideComment: Error updating comment visibility.")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// unhideComment makes a hidden comment visible again.
+func (rt *_router) unhideComment(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the user ID from the path parameters.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("unhideComment: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the photo ID from the path parameters.
+	photoID, err := strconv.Atoi(ps.ByName("photoid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("unhideComment: Invalid photo ID format.")
+		return
+	}
+
+	// Extract the comment ID from the path parameters.
+	commentID, err := strconv.Atoi(ps.ByName("commentid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("unhideComment: Invalid comment ID format.")
+		return
+	}
+
+	// Update the comment visibility.
+	if err := rt.db.SetCommentHidden(userID, photoID, commentID, false); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// If any of the input IDs do not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("unhideComment: Not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("unhideComment: Error updating comment visibility.")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// updatePhoto changes the settings of a photo, such as whether it accepts comments.
+func (rt *_router) updatePhoto(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the user ID from the path parameters.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("updatePhoto: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the photo ID from the path parameters.
+	photoID, err := strconv.Atoi(ps.ByName("photoid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("updatePhoto: Invalid photo ID format.")
+		return
+	}
+
+	// Extract the new settings from the request body.
+	var settings struct {
+		CommentsEnabled *bool `json:"commentsEnabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil || settings.CommentsEnabled == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("updatePhoto: Invalid request.")
+		return
+	}
+
+	// Turn comments on or off.
+	if err := rt.db.SetCommentsEnabled(userID, photoID, *settings.CommentsEnabled); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The photo does not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("updatePhoto: Photo not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("updatePhoto: Error updating photo.")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// getPhoto returns a single photo, with its likes and comments.
+func (rt *_router) getPhoto(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user who published the photo from the path.
+	ownerID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getPhoto: Invalid user ID format.")
+		return
+	}
+
+	// Extract the photo ID from the path parameters.
+	photoID, err := strconv.Atoi(ps.ByName("photoid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getPhoto: Invalid photo ID format.")
+		return
+	}
+
+	// Extract the ID of the user making the request.
+	requestingUserID, err := strconv.Atoi(extractBearer(r.Header.Get("Authorization")))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getPhoto: error during authorization")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(requestingUserID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Get the photo from the database.
+	photo, err := rt.db.GetPhoto(requestingUserID, ownerID, photoID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The photo does not exist, or it is not visible to the user.
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getPhoto: Error getting photo.")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(photo)
+}
+
+// getPhotoLikes returns a page of the likes on a photo, with the username of each user who liked it.
+func (rt *_router) getPhotoLikes(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user who published the photo from the path.
+	ownerID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getPhotoLikes: Invalid user ID format.")
+		return
+	}
+
+	// Extract the ID of the user making the request.
+	requestingUserID, err := strconv.Atoi(extractBearer(r.Header.Get("Authorization")))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getPhotoLikes: error during authorization")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(requestingUserID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the photo ID from the path parameters.
+	photoID, err := strconv.Atoi(ps.ByName("photoid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getPhotoLikes: Invalid photo ID format.")
+		return
+	}
+
+	// Extract the requested page.
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getPhotoLikes: Invalid pagination.")
+		return
+	}
+
+	// Get the page from the database.
+	dbItems, err := rt.db.GetPhotoLikes(requestingUserID, ownerID, photoID, limit, offset)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The photo does not exist, it was not published by the user, or it is not visible: return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("getPhotoLikes: Photo not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getPhotoLikes: Error getting likes.")
+		return
+	}
+
+	items := make([]Like, len(dbItems))
+	for i, item := range dbItems {
+		items[i].LikeFromDatabase(item)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(items)
+}
+
+// getPhotoComments returns a page of the comments on a photo, oldest first.
+func (rt *_router) getPhotoComments(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user who published the photo from the path.
+	ownerID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getPhotoComments: Invalid user ID format.")
+		return
+	}
+
+	// Extract the ID of the user making the request.
+	requestingUserID, err := strconv.Atoi(extractBearer(r.Header.Get("Authorization")))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getPhotoComments: error during authorization")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(requestingUserID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the photo ID from the path parameters.
+	photoID, err := strconv.Atoi(ps.ByName("photoid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getPhotoComments: Invalid photo ID format.")
+		return
+	}
+
+	// Extract the requested page.
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getPhotoComments: Invalid pagination.")
+		return
+	}
+
+	// Get the page from the database.
+	dbItems, err := rt.db.GetPhotoComments(requestingUserID, ownerID, photoID, limit, offset)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The photo does not exist, it was not published by the user, or it is not visible: return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("getPhotoComments: Photo not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getPhotoComments: Error getting comments.")
+		return
+	}
+
+	items := make([]Comment, len(dbItems))
+	for i, item := range dbItems {
+		items[i].CommentFromDatabase(item)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(items)
+}
+
 // deletePhoto removes a photo.
 func (rt *_router) deletePhoto(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
 	w.Header().Set("Content-Type", "application/json")
@@ -322,7 +828,7 @@ func (rt *_router) deletePhoto(w http.ResponseWriter, r *http.Request, ps httpro
 	}
 
 	// Remove the photo from database.
-	if err := rt.db.DeletePhoto(userID, photoID); err != nil {
+	if err := rt.db.ForRequest(ctx.ReqUUID.String()).DeletePhoto(userID, photoID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			// The photo does not exist, return a NotFound status.
 			w.WriteHeader(http.StatusNotFound)
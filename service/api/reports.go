@@ -0,0 +1,267 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/api/reqcontext"
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/database"
+	"github.com/julienschmidt/httprouter"
+)
+
+// reportPhoto flags a photo as abusive.
+func (rt *_router) reportPhoto(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the user ID from the path parameters.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("reportPhoto: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the photo ID from the path parameters.
+	photoID, err := strconv.Atoi(ps.ByName("photoid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("reportPhoto: Invalid photo ID format.")
+		return
+	}
+
+	// Extract the reason and details from the request body.
+	report, err := readReport(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("reportPhoto: Invalid request.")
+		return
+	}
+	report.ReporterID = userID
+	report.TargetType = database.ReportTargetPhoto
+	report.TargetID = photoID
+
+	rt.createReport(w, report, ctx, "reportPhoto")
+}
+
+// reportComment flags a comment as abusive.
+func (rt *_router) reportComment(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the user ID from the path parameters.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("reportComment: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the comment ID from the path parameters.
+	commentID, err := strconv.Atoi(ps.ByName("commentid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("reportComment: Invalid comment ID format.")
+		return
+	}
+
+	// Extract the reason and details from the request body.
+	report, err := readReport(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("reportComment: Invalid request.")
+		return
+	}
+	report.ReporterID = userID
+	report.TargetType = database.ReportTargetComment
+	report.TargetID = commentID
+
+	rt.createReport(w, report, ctx, "reportComment")
+}
+
+// reportUser flags a user as abusive. The ID of the reported user is the targetID of the report.
+func (rt *_router) reportUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("reportUser: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the reported user, the reason and details from the request body.
+	report, err := readReport(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("reportUser: Invalid request.")
+		return
+	}
+	report.ReporterID = userID
+	report.TargetType = database.ReportTargetUser
+
+	rt.createReport(w, report, ctx, "reportUser")
+}
+
+// createReport stores a new report and writes it in the response.
+func (rt *_router) createReport(w http.ResponseWriter, report Report, ctx reqcontext.RequestContext, handler string) {
+	dbReport, err := rt.db.CreateReport(report.ReportToDatabase())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The reported content does not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error(handler + ": Reported content not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error(handler + ": Error creating report.")
+		return
+	}
+
+	report.ReportFromDatabase(dbReport)
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// getReports returns the moderation queue. Only moderators and admins can access it.
+func (rt *_router) getReports(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Authorization
+	_, authorizationStatus := rt.validateRole(extractBearer(r.Header.Get("Authorization")), database.RoleModerator, database.RoleAdmin)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Open reports are returned unless another status is requested.
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = database.ReportStatusOpen
+	}
+	if status != database.ReportStatusOpen && status != database.ReportStatusResolved && status != database.ReportStatusDismissed {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.Error("getReports: Invalid status.")
+		return
+	}
+
+	// Extract the requested page.
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getReports: Invalid pagination.")
+		return
+	}
+
+	dbReports, err := rt.db.GetReports(status, limit, offset)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getReports: Error fetching reports.")
+		return
+	}
+
+	reports := make([]Report, len(dbReports))
+	for i, report := range dbReports {
+		reports[i].ReportFromDatabase(report)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(reports)
+}
+
+// decideReport records the decision taken by a moderator on a report: removing the content, suspending its author or
+// dismissing the report.
+func (rt *_router) decideReport(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Authorization
+	moderatorID, authorizationStatus := rt.validateRole(extractBearer(r.Header.Get("Authorization")), database.RoleModerator, database.RoleAdmin)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the report ID from the path parameters.
+	reportID, err := strconv.Atoi(ps.ByName("reportid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("decideReport: Invalid report ID format.")
+		return
+	}
+
+	// Extract the decision from the request body.
+	var decision struct {
+		Action string `json:"action"`
+		Note   string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&decision); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("decideReport: Invalid request.")
+		return
+	}
+
+	decision.Note = strings.TrimSpace(decision.Note)
+	if utf8.RuneCountInString(decision.Note) > maxReportDetailsLength {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.Error("decideReport: Note too long.")
+		return
+	}
+
+	dbReport, err := rt.db.ForRequest(ctx.ReqUUID.String()).ResolveReport(moderatorID, reportID, decision.Action, decision.Note)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The report does not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("decideReport: Report not found.")
+			return
+		}
+		if errors.Is(err, database.ErrStaffSuspension) {
+			// Only admins can suspend moderators and admins.
+			w.WriteHeader(http.StatusForbidden)
+			ctx.Logger.WithError(err).Error("decideReport: Cannot suspend a moderator or an admin.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("decideReport: Error resolving report.")
+		return
+	}
+
+	var report Report
+	report.ReportFromDatabase(dbReport)
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -2,9 +2,11 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/api/reqcontext"
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/database"
 	"github.com/julienschmidt/httprouter"
 )
 
@@ -22,15 +24,19 @@ func (rt *_router) doLogin(w http.ResponseWriter, r *http.Request, ps httprouter
 	}
 
 	// Check if the username meets the requirements.
-	if !isValidUsername(user.Username) {
+	if !IsValidUsername(user.Username) {
 		w.WriteHeader(http.StatusBadRequest)
 		ctx.Logger.Error("Login: Invalid username format. Please follow the specified requirements.")
 		return
 	}
 
 	//  Attempt to create a new user or retrieve an existing user from the database.
-	newUser, err := rt.db.CreateUser(user.UserToDatabase())
-	if err != nil {
+	newUser, err := rt.db.ForRequest(ctx.ReqUUID.String()).CreateUser(user.UserToDatabase())
+	if errors.Is(err, database.ErrUserSuspended) {
+		w.WriteHeader(http.StatusForbidden)
+		ctx.Logger.WithError(err).Error("Login: Suspended account")
+		return
+	} else if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
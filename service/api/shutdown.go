@@ -2,5 +2,13 @@ package api
 
 // Close should close everything opened in the lifecycle of the `_router`; for example, background goroutines.
 func (rt *_router) Close() error {
+	// End the event streams, so that the server does not wait for them.
+	rt.events.close()
+
+	// Stop recomputing the trending photos.
+	rt.trending.close()
+
+	// Stop the webhook deliveries; the interrupted ones are retried on the next start.
+	rt.webhooks.Close()
 	return nil
 }
@@ -28,13 +28,14 @@ func (u *User) UserToDatabase() database.User {
 
 // Photo structure.
 type Photo struct {
-	UserID        int       `json:"userID"`
-	PhotoID       int       `json:"photoID"`
-	Username      string    `json:"username"`
-	ImageData     []byte    `json:"imageData"`
-	UploadDate    time.Time `json:"uploadDate"`
-	LikesCount    int       `json:"likesCount"`
-	CommentsCount int       `json:"commentsCount"`
+	UserID          int       `json:"userID"`
+	PhotoID         int       `json:"photoID"`
+	Username        string    `json:"username"`
+	ImageData       []byte    `json:"imageData"`
+	UploadDate      time.Time `json:"uploadDate"`
+	LikesCount      int       `json:"likesCount"`
+	CommentsCount   int       `json:"commentsCount"`
+	CommentsEnabled bool      `json:"commentsEnabled"`
 }
 
 // PhotoFromDatabase updates the current Photo struct with data from a database.Photo struct.
@@ -46,78 +47,91 @@ func (p *Photo) PhotoFromDatabase(photo database.Photo) {
 	p.UploadDate = photo.UploadDate
 	p.LikesCount = photo.LikesCount
 	p.CommentsCount = photo.CommentsCount
+	p.CommentsEnabled = photo.CommentsEnabled
 }
 
 // PhotoToDatabase converts the current Photo struct to a database.Photo struct.
 func (p *Photo) PhotoToDatabase() database.Photo {
 	return database.Photo{
-		PhotoID:       p.PhotoID,
-		UserID:        p.UserID,
-		Username:      p.Username,
-		ImageData:     p.ImageData,
-		UploadDate:    p.UploadDate,
-		LikesCount:    p.LikesCount,
-		CommentsCount: p.CommentsCount,
+		PhotoID:         p.PhotoID,
+		UserID:          p.UserID,
+		Username:        p.Username,
+		ImageData:       p.ImageData,
+		UploadDate:      p.UploadDate,
+		LikesCount:      p.LikesCount,
+		CommentsCount:   p.CommentsCount,
+		CommentsEnabled: p.CommentsEnabled,
 	}
 }
 
 // CompletePhoto represents a photo object that includes the author's username, the image URL, the number of "likes" and comments,
 // and details about users who have liked or commented, including the likes and comments themselves.
 type CompletePhoto struct {
-	UserID        int       `json:"userID"`
-	PhotoID       int       `json:"photoID"`
-	Username      string    `json:"username"`
-	ImageData     []byte    `json:"imageData"`
-	UploadDate    time.Time `json:"uploadDate"`
-	LikesCount    int       `json:"likesCount"`
-	Likes         []Like    `json:"likes"`
-	CommentsCount int       `json:"commentsCount"`
-	Comments      []Comment `json:"comments"`
+	UserID          int       `json:"userID"`
+	PhotoID         int       `json:"photoID"`
+	Username        string    `json:"username"`
+	ImageData       []byte    `json:"imageData"`
+	UploadDate      time.Time `json:"uploadDate"`
+	LikesCount      int       `json:"likesCount"`
+	Likes           []Like    `json:"likes"`
+	CommentsCount   int       `json:"commentsCount"`
+	Comments        []Comment `json:"comments"`
+	CommentsEnabled bool      `json:"commentsEnabled"`
+	LikedByViewer   bool      `json:"likedByViewer"`
+	ViewerLikeID    int       `json:"viewerLikeID,omitempty"`
 }
 
 // Profile structure that includes the number of "followers", "following" and photo uploaded, including their arrays
 type Profile struct {
-	UserID              int             `json:"userID"`              // User's identifier
-	Username            string          `json:"username"`            // User's username
-	Followers           []User          `json:"followers"`           // followers list
-	Following           []User          `json:"following"`           // following list
-	FollowersCount      int             `json:"followersCount"`      // followers number
-	FollowingCount      int             `json:"followingCount"`      // following number
-	UploadedPhotos      []CompletePhoto `json:"uploadedPhotos"`      // Photos array
-	UploadedPhotosCount int             `json:"uploadedPhotosCount"` // Uploaded photos number
+	UserID               int             `json:"userID"`               // User's identifier
+	Username             string          `json:"username"`             // User's username
+	Private              bool            `json:"private"`              // True if only approved followers can see the content
+	Followers            []User          `json:"followers"`            // followers list
+	Following            []User          `json:"following"`            // following list
+	FollowersCount       int             `json:"followersCount"`       // followers number
+	FollowingCount       int             `json:"followingCount"`       // following number
+	UploadedPhotos       []CompletePhoto `json:"uploadedPhotos"`       // Photos array
+	UploadedPhotosCount  int             `json:"uploadedPhotosCount"`  // Uploaded photos number
+	MutualFollowers      []User          `json:"mutualFollowers"`      // First users followed by the viewer who follow this user
+	MutualFollowersCount int             `json:"mutualFollowersCount"` // Number of users followed by the viewer who follow this user
 }
 
 // Like structure.
 type Like struct {
-	LikeID  int `json:"likeID"`
-	UserID  int `json:"userID"`
-	PhotoID int `json:"photoID"`
+	LikeID   int    `json:"likeID"`
+	UserID   int    `json:"userID"`
+	Username string `json:"username"`
+	PhotoID  int    `json:"photoID"`
 }
 
 // LikeFromDatabase updates the current Like struct with data from a database.Like struct.
 func (l *Like) LikeFromDatabase(like database.Like) {
 	l.LikeID = like.LikeID
 	l.UserID = like.UserID
+	l.Username = like.Username
 	l.PhotoID = like.PhotoID
 }
 
 // LikeToDatabase converts the current Like struct to a database.Like struct.
 func (l *Like) LikeToDatabase() database.Like {
 	return database.Like{
-		LikeID:  l.LikeID,
-		UserID:  l.UserID,
-		PhotoID: l.PhotoID,
+		LikeID:   l.LikeID,
+		UserID:   l.UserID,
+		Username: l.Username,
+		PhotoID:  l.PhotoID,
 	}
 }
 
 // Comment structure.
 type Comment struct {
-	CommentID      int       `json:"commentID"`
-	AuthorID       int       `json:"authorID"`
-	AuthorUsername string    `json:"authorUsername"`
-	PhotoID        int       `json:"photoID"`
-	CommentText    string    `json:"commentText"`
-	UploadDate     time.Time `json:"uploadDate"`
+	CommentID      int        `json:"commentID"`
+	AuthorID       int        `json:"authorID"`
+	AuthorUsername string     `json:"authorUsername"`
+	PhotoID        int        `json:"photoID"`
+	CommentText    string     `json:"commentText"`
+	UploadDate     time.Time  `json:"uploadDate"`
+	EditedAt       *time.Time `json:"editedAt,omitempty"`
+	Hidden         bool       `json:"hidden"`
 }
 
 // CommentFromDatabase updates the current Comment struct with data from a database.Comment struct.
@@ -128,6 +142,8 @@ func (c *Comment) CommentFromDatabase(comment database.Comment) {
 	c.PhotoID = comment.PhotoID
 	c.CommentText = comment.CommentText
 	c.UploadDate = comment.UploadDate
+	c.EditedAt = comment.EditedAt
+	c.Hidden = comment.Hidden
 }
 
 // CommentToDatabase converts the current Comment struct to a database.Comment struct.
@@ -139,5 +155,345 @@ func (c *Comment) CommentToDatabase() database.Comment {
 		PhotoID:        c.PhotoID,
 		CommentText:    c.CommentText,
 		UploadDate:     c.UploadDate,
+		EditedAt:       c.EditedAt,
+		Hidden:         c.Hidden,
 	}
 }
+
+// CommentRevision structure.
+type CommentRevision struct {
+	RevisionID  int       `json:"revisionID"`
+	CommentID   int       `json:"commentID"`
+	CommentText string    `json:"commentText"`
+	RevisedAt   time.Time `json:"revisedAt"`
+}
+
+// CommentRevisionFromDatabase updates the current CommentRevision struct with data from a database.CommentRevision struct.
+func (cr *CommentRevision) CommentRevisionFromDatabase(revision database.CommentRevision) {
+	cr.RevisionID = revision.RevisionID
+	cr.CommentID = revision.CommentID
+	cr.CommentText = revision.CommentText
+	cr.RevisedAt = revision.RevisedAt
+}
+
+// Ban structure.
+type Ban struct {
+	UserID    int        `json:"userID"`
+	Username  string     `json:"username"`
+	Reason    string     `json:"reason,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// BanFromDatabase updates the current Ban struct with data from a database.Ban struct.
+func (b *Ban) BanFromDatabase(ban database.Ban) {
+	b.UserID = ban.UserID
+	b.Username = ban.Username
+	b.Reason = ban.Reason
+	b.CreatedAt = ban.CreatedAt
+	b.ExpiresAt = ban.ExpiresAt
+}
+
+// BanToDatabase converts the current Ban struct to a database.Ban struct.
+func (b *Ban) BanToDatabase() database.Ban {
+	return database.Ban{
+		UserID:    b.UserID,
+		Username:  b.Username,
+		Reason:    b.Reason,
+		CreatedAt: b.CreatedAt,
+		ExpiresAt: b.ExpiresAt,
+	}
+}
+
+// Report structure.
+type Report struct {
+	ReportID   int        `json:"reportID"`
+	ReporterID int        `json:"reporterID"`
+	TargetType string     `json:"targetType"`
+	TargetID   int        `json:"targetID"`
+	Reason     string     `json:"reason"`
+	Details    string     `json:"details,omitempty"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ResolverID int        `json:"resolverID,omitempty"`
+	Action     string     `json:"action,omitempty"`
+	Note       string     `json:"note,omitempty"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+}
+
+// ReportFromDatabase updates the current Report struct with data from a database.Report struct.
+func (rp *Report) ReportFromDatabase(report database.Report) {
+	rp.ReportID = report.ReportID
+	rp.ReporterID = report.ReporterID
+	rp.TargetType = report.TargetType
+	rp.TargetID = report.TargetID
+	rp.Reason = report.Reason
+	rp.Details = report.Details
+	rp.Status = report.Status
+	rp.CreatedAt = report.CreatedAt
+	rp.ResolverID = report.ResolverID
+	rp.Action = report.Action
+	rp.Note = report.Note
+	rp.ResolvedAt = report.ResolvedAt
+}
+
+// ReportToDatabase converts the current Report struct to a database.Report struct.
+func (rp *Report) ReportToDatabase() database.Report {
+	return database.Report{
+		ReportID:   rp.ReportID,
+		ReporterID: rp.ReporterID,
+		TargetType: rp.TargetType,
+		TargetID:   rp.TargetID,
+		Reason:     rp.Reason,
+		Details:    rp.Details,
+		Status:     rp.Status,
+		CreatedAt:  rp.CreatedAt,
+		ResolverID: rp.ResolverID,
+		Action:     rp.Action,
+		Note:       rp.Note,
+		ResolvedAt: rp.ResolvedAt,
+	}
+}
+
+// AuditEvent structure.
+type AuditEvent struct {
+	EventID    int       `json:"eventID"`
+	ActorID    int       `json:"actorID,omitempty"`
+	Action     string    `json:"action"`
+	TargetType string    `json:"targetType"`
+	TargetID   int       `json:"targetID"`
+	Details    string    `json:"details,omitempty"`
+	RequestID  string    `json:"requestID,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// AuditEventFromDatabase updates the current AuditEvent struct with data from a database.AuditEvent struct.
+func (e *AuditEvent) AuditEventFromDatabase(event database.AuditEvent) {
+	e.EventID = event.EventID
+	e.ActorID = event.ActorID
+	e.Action = event.Action
+	e.TargetType = event.TargetType
+	e.TargetID = event.TargetID
+	e.Details = event.Details
+	e.RequestID = event.RequestID
+	e.CreatedAt = event.CreatedAt
+}
+
+// Suggestion structure.
+type Suggestion struct {
+	UserID          int    `json:"userID"`
+	Username        string `json:"username"`
+	Reason          string `json:"reason"`
+	FollowedByCount int    `json:"followedByCount"`
+	FollowsViewer   bool   `json:"followsViewer"`
+}
+
+// SuggestionFromDatabase updates the current Suggestion struct with data from a database.Suggestion struct.
+func (s *Suggestion) SuggestionFromDatabase(suggestion database.Suggestion) {
+	s.UserID = suggestion.UserID
+	s.Username = suggestion.Username
+	s.Reason = suggestion.Reason
+	s.FollowedByCount = suggestion.FollowedByCount
+	s.FollowsViewer = suggestion.FollowsViewer
+}
+
+// Relationship structure.
+type Relationship struct {
+	UserID                int  `json:"userID"`
+	Following             bool `json:"following"`
+	FollowedBy            bool `json:"followedBy"`
+	Banned                bool `json:"banned"`
+	BannedBy              bool `json:"bannedBy"`
+	Muted                 bool `json:"muted"`
+	FollowRequested       bool `json:"followRequested"`
+	FollowRequestReceived bool `json:"followRequestReceived"`
+}
+
+// RelationshipFromDatabase updates the current Relationship struct with data from a database.Relationship struct.
+func (rs *Relationship) RelationshipFromDatabase(relationship database.Relationship) {
+	rs.UserID = relationship.UserID
+	rs.Following = relationship.Following
+	rs.FollowedBy = relationship.FollowedBy
+	rs.Banned = relationship.Banned
+	rs.BannedBy = relationship.BannedBy
+	rs.Muted = relationship.Muted
+	rs.FollowRequested = relationship.FollowRequested
+	rs.FollowRequestReceived = relationship.FollowRequestReceived
+}
+
+// Notification structure.
+type Notification struct {
+	NotificationID int       `json:"notificationID"`
+	Type           string    `json:"type"`
+	ActorID        int       `json:"actorID"`
+	ActorUsername  string    `json:"actorUsername"`
+	ActorsCount    int       `json:"actorsCount"`
+	PhotoID        int       `json:"photoID,omitempty"`
+	CommentID      int       `json:"commentID,omitempty"`
+	Message        string    `json:"message"`
+	Read           bool      `json:"read"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// NotificationFromDatabase updates the current Notification struct with data from a database.Notification struct.
+func (n *Notification) NotificationFromDatabase(notification database.Notification) {
+	n.NotificationID = notification.NotificationID
+	n.Type = notification.Type
+	n.ActorID = notification.ActorID
+	n.ActorUsername = notification.ActorUsername
+	n.ActorsCount = notification.ActorsCount
+	n.PhotoID = notification.PhotoID
+	n.CommentID = notification.CommentID
+	n.Message = notification.Message
+	n.Read = notification.Read
+	n.CreatedAt = notification.CreatedAt
+}
+
+// NotificationPreferences structure.
+type NotificationPreferences struct {
+	Events      map[string]NotificationChannels `json:"events"`
+	PausedUntil *time.Time                      `json:"pausedUntil"`
+}
+
+// NotificationChannels structure.
+type NotificationChannels struct {
+	InApp bool `json:"inApp"`
+	Push  bool `json:"push"`
+	Email bool `json:"email"`
+}
+
+// NotificationPreferencesFromDatabase updates the current NotificationPreferences struct with data from a
+// database.NotificationPreferences struct.
+func (np *NotificationPreferences) NotificationPreferencesFromDatabase(preferences database.NotificationPreferences) {
+	np.Events = make(map[string]NotificationChannels, len(preferences.Events))
+	for event, channels := range preferences.Events {
+		np.Events[event] = NotificationChannels(channels)
+	}
+	np.PausedUntil = preferences.PausedUntil
+}
+
+// NotificationPreferencesToDatabase converts the current NotificationPreferences struct to a
+// database.NotificationPreferences struct.
+func (np *NotificationPreferences) NotificationPreferencesToDatabase() database.NotificationPreferences {
+	events := make(map[string]database.NotificationChannels, len(np.Events))
+	for event, channels := range np.Events {
+		events[event] = database.NotificationChannels(channels)
+	}
+	return database.NotificationPreferences{
+		Events:      events,
+		PausedUntil: np.PausedUntil,
+	}
+}
+
+// StreamMarker structure.
+type StreamMarker struct {
+	LastSeen *time.Time `json:"lastSeen"`
+	NewCount int        `json:"newCount"`
+}
+
+// StreamMarkerFromDatabase updates the current StreamMarker struct with data from a database.StreamMarker struct.
+func (sm *StreamMarker) StreamMarkerFromDatabase(marker database.StreamMarker) {
+	sm.LastSeen = marker.LastSeen
+	sm.NewCount = marker.NewCount
+}
+
+// Stream structure, with the number of photos uploaded since the user last saw their stream.
+type Stream struct {
+	Photos   []database.CompletePhoto `json:"photos"`
+	NewCount int                      `json:"newCount"`
+}
+
+// Collection structure.
+type Collection struct {
+	CollectionID int       `json:"collectionID"`
+	OwnerID      int       `json:"ownerID"`
+	Name         string    `json:"name"`
+	PhotosCount  int       `json:"photosCount"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// CollectionFromDatabase updates the current Collection struct with data from a database.Collection struct.
+func (c *Collection) CollectionFromDatabase(collection database.Collection) {
+	c.CollectionID = collection.CollectionID
+	c.OwnerID = collection.OwnerID
+	c.Name = collection.Name
+	c.PhotosCount = collection.PhotosCount
+	c.CreatedAt = collection.CreatedAt
+}
+
+// CollectionToDatabase converts the current Collection struct to a database.Collection struct.
+func (c *Collection) CollectionToDatabase() database.Collection {
+	return database.Collection{
+		CollectionID: c.CollectionID,
+		OwnerID:      c.OwnerID,
+		Name:         c.Name,
+		PhotosCount:  c.PhotosCount,
+		CreatedAt:    c.CreatedAt,
+	}
+}
+
+// Webhook structure.
+type Webhook struct {
+	WebhookID int       `json:"webhookID"`
+	OwnerID   int       `json:"ownerID"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WebhookFromDatabase updates the current Webhook struct with data from a database.Webhook struct.
+func (wh *Webhook) WebhookFromDatabase(webhook database.Webhook) {
+	wh.WebhookID = webhook.WebhookID
+	wh.OwnerID = webhook.OwnerID
+	wh.URL = webhook.URL
+	wh.Secret = webhook.Secret
+	wh.Events = webhook.Events
+	wh.CreatedAt = webhook.CreatedAt
+}
+
+// WebhookToDatabase converts the current Webhook struct to a database.Webhook struct.
+func (wh *Webhook) WebhookToDatabase() database.Webhook {
+	return database.Webhook{
+		WebhookID: wh.WebhookID,
+		OwnerID:   wh.OwnerID,
+		URL:       wh.URL,
+		Secret:    wh.Secret,
+		Events:    wh.Events,
+		CreatedAt: wh.CreatedAt,
+	}
+}
+
+// WebhookDelivery structure.
+type WebhookDelivery struct {
+	DeliveryID     int        `json:"deliveryID"`
+	WebhookID      int        `json:"webhookID"`
+	Event          string     `json:"event"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt,omitempty"`
+	ResponseStatus int        `json:"responseStatus,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+}
+
+// WebhookDeliveryFromDatabase updates the current WebhookDelivery struct with data from a database.WebhookDelivery
+// struct. The next attempt is only reported for pending deliveries.
+func (wd *WebhookDelivery) WebhookDeliveryFromDatabase(delivery database.WebhookDelivery) {
+	wd.DeliveryID = delivery.DeliveryID
+	wd.WebhookID = delivery.WebhookID
+	wd.Event = delivery.Event
+	wd.Payload = delivery.Payload
+	wd.Status = delivery.Status
+	wd.Attempts = delivery.Attempts
+	if delivery.Status == database.WebhookDeliveryPending {
+		nextAttemptAt := delivery.NextAttemptAt
+		wd.NextAttemptAt = &nextAttemptAt
+	}
+	wd.ResponseStatus = delivery.ResponseStatus
+	wd.LastError = delivery.LastError
+	wd.CreatedAt = delivery.CreatedAt
+	wd.DeliveredAt = delivery.DeliveredAt
+}
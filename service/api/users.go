@@ -6,8 +6,11 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
+	"unicode/utf8"
 
 	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/api/reqcontext"
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/database"
 	"github.com/julienschmidt/httprouter"
 )
 
@@ -40,14 +43,14 @@ func (rt *_router) setMyUserName(w http.ResponseWriter, r *http.Request, ps http
 	}
 
 	// Check if the username meets the requirements.
-	if !isValidUsername(user.Username) {
+	if !IsValidUsername(user.Username) {
 		w.WriteHeader(http.StatusBadRequest)
 		ctx.Logger.Error("setMyUserName: Invalid username format. Please follow the specified requirements.")
 		return
 	}
 
 	// Update the username in the database.
-	if err := rt.db.UpdateUsername(userID, user.Username); err != nil {
+	if err := rt.db.ForRequest(ctx.ReqUUID.String()).UpdateUsername(userID, user.Username); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		ctx.Logger.WithError(err).Error("setMyUserName: Error updating username in the database.")
 		return
@@ -56,6 +59,77 @@ func (rt *_router) setMyUserName(w http.ResponseWriter, r *http.Request, ps http
 	w.WriteHeader(http.StatusOK)
 }
 
+// updateProfile updates the settings of the specified user's profile.
+func (rt *_router) updateProfile(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("updateProfile: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the new settings from the request body. Settings that are missing are left unchanged.
+	var settings struct {
+		Private     *bool   `json:"private"`
+		Email       *string `json:"email"`
+		EmailDigest *string `json:"emailDigest"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil || (settings.Private == nil && settings.Email == nil && settings.EmailDigest == nil) {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("updateProfile: Invalid request.")
+		return
+	}
+	if settings.Email != nil && *settings.Email != "" && !isValidEmail(*settings.Email) {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.Error("updateProfile: Invalid email address.")
+		return
+	}
+	if settings.EmailDigest != nil && !digestFrequencies[*settings.EmailDigest] {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.Error("updateProfile: Invalid email digest frequency.")
+		return
+	}
+
+	// Make the account private or public.
+	if settings.Private != nil {
+		if err := rt.db.SetPrivate(userID, *settings.Private); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			ctx.Logger.WithError(err).Error("updateProfile: Error updating profile.")
+			return
+		}
+	}
+
+	// Set or remove the email address.
+	if settings.Email != nil {
+		if err := rt.db.SetEmail(userID, *settings.Email); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			ctx.Logger.WithError(err).Error("updateProfile: Error updating email.")
+			return
+		}
+	}
+
+	// Change how often the email digests are sent.
+	if settings.EmailDigest != nil {
+		if err := rt.db.SetEmailDigest(userID, *settings.EmailDigest); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			ctx.Logger.WithError(err).Error("updateProfile: Error updating email digest.")
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
 // getUserProfile returns the profile of the specified user.
 func (rt *_router) getUserProfile(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
 	w.Header().Set("Content-Type", "application/json")
@@ -87,8 +161,8 @@ func (rt *_router) getUserProfile(w http.ResponseWriter, r *http.Request, ps htt
 	// Call the database function to get the user profile details.
 	profile, err := rt.db.GetUserProfile(requestingUserID, requestedUserID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			// Return a 404 error if the user does not exist.
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, database.ErrBannedByUser) {
+			// Return a 404 error if the user does not exist, or if they banned the requesting user.
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
@@ -101,6 +175,67 @@ func (rt *_router) getUserProfile(w http.ResponseWriter, r *http.Request, ps htt
 	_ = json.NewEncoder(w).Encode(profile)
 }
 
+// getMutualFollowers returns the users followed by the user making the request who also follow the specified user,
+// with their total number.
+func (rt *_router) getMutualFollowers(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user whose followers are to be viewed from the path.
+	requestedUserID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getMutualFollowers: Invalid user ID format.")
+		return
+	}
+
+	// Extract the ID of the user making the request.
+	requestingUserID, err := strconv.Atoi(extractBearer(r.Header.Get("Authorization")))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getMutualFollowers: error during authorization")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(requestingUserID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the requested page.
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getMutualFollowers: Invalid pagination.")
+		return
+	}
+
+	dbUsers, count, err := rt.db.GetMutualFollowers(requestingUserID, requestedUserID, limit, offset)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, database.ErrBannedByUser) {
+			// Return a 404 error if the user does not exist, or if they banned the requesting user.
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getMutualFollowers: Error fetching mutual followers.")
+		return
+	}
+
+	response := struct {
+		Count int    `json:"count"`
+		Users []User `json:"users"`
+	}{Count: count, Users: make([]User, len(dbUsers))}
+	for i, user := range dbUsers {
+		response.Users[i].UserFromDatabase(user)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
 // followUser adds a user to the specified user's following list.
 func (rt *_router) followUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
 	w.Header().Set("Content-Type", "application/json")
@@ -130,12 +265,21 @@ func (rt *_router) followUser(w http.ResponseWriter, r *http.Request, ps httprou
 	}
 
 	// Follow the user.
-	if err := rt.db.FollowUser(followerID, followingUser.UserID); err != nil {
+	pending, err := rt.db.FollowUser(followerID, followingUser.UserID)
+	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		ctx.Logger.WithError(err).Error("followUser: Error following user in the database.")
 		return
 	}
 
+	if pending {
+		// The user has a private account: the follow request waits for their approval.
+		rt.publishUnlessMuted(followingUser.UserID, eventFollowRequest, eventData{ActorID: followerID}, ctx)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	rt.publishUnlessMuted(followingUser.UserID, eventFollow, eventData{ActorID: followerID}, ctx)
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -211,16 +355,29 @@ func (rt *_router) banUser(w http.ResponseWriter, r *http.Request, ps httprouter
 		return
 	}
 
-	// Extract the user ID of the user to be banned from the request body.
-	var bannedUser User
-	if err := json.NewDecoder(r.Body).Decode(&bannedUser); err != nil {
+	// Extract the user ID of the user to be banned, the optional reason and expiration date from the request body.
+	var ban Ban
+	if err := json.NewDecoder(r.Body).Decode(&ban); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		ctx.Logger.WithError(err).Error("banUser: Invalid request.")
 		return
 	}
 
+	// Check if the reason and the expiration date are valid.
+	ban.CreatedAt = time.Now()
+	if utf8.RuneCountInString(ban.Reason) > maxBanReasonLength {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.Error("banUser: Ban reason too long.")
+		return
+	}
+	if ban.ExpiresAt != nil && !ban.ExpiresAt.After(ban.CreatedAt) {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.Error("banUser: Ban expiration date must be in the future.")
+		return
+	}
+
 	// Ban the user
-	if err := rt.db.BanUser(userID, bannedUser.UserID); err != nil {
+	if err := rt.db.ForRequest(ctx.ReqUUID.String()).BanUser(userID, ban.UserID, ban.BanToDatabase()); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		ctx.Logger.WithError(err).Error("banUser: Error banning user in the database.")
 		return
@@ -265,7 +422,7 @@ func (rt *_router) unbanUser(w http.ResponseWriter, r *http.Request, ps httprout
 	}
 
 	// Unban the user.
-	if err := rt.db.UnbanUser(userID, bannedUserID); err != nil {
+	if err := rt.db.ForRequest(ctx.ReqUUID.String()).UnbanUser(userID, bannedUserID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			// Return a NotFound status if either the user or the banned user does not exist.
 			w.WriteHeader(http.StatusNotFound)
@@ -282,7 +439,15 @@ func (rt *_router) unbanUser(w http.ResponseWriter, r *http.Request, ps httprout
 	w.WriteHeader(http.StatusOK)
 }
 
-// getMyStream returns the stream of the user, consisting of photos from people the user follows.
+// Modes of the stream.
+const (
+	streamModeChronological = "chronological" // Photos of the followed users, most recent first
+	streamModeRanked        = "ranked"        // Photos scored by freshness, popularity and affinity with the author
+)
+
+// getMyStream returns the stream of the user, consisting of photos from people the user follows. In ranked mode, the
+// photos are ordered by score, and popular photos from other users may be included. In chronological mode, the stream
+// can be limited to the photos uploaded after a date, such as the last seen one.
 func (rt *_router) getMyStream(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -301,8 +466,58 @@ func (rt *_router) getMyStream(w http.ResponseWriter, r *http.Request, ps httpro
 		return
 	}
 
-	// Call the database function to get the user's stream.
-	stream, err := rt.db.GetMyStream(userID)
+	// Get how far the user has seen their stream, to count the new photos.
+	marker, err := rt.db.GetStreamMarker(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Return a NotFound status if the user does not exist.
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getMyStream: Error getting stream marker.")
+		return
+	}
+
+	// Extract the date the photos must be uploaded after, if any. "lastSeen" stands for the date of the stream marker.
+	var since time.Time
+	if value := r.URL.Query().Get("since"); value == "lastSeen" {
+		if marker.LastSeen != nil {
+			since = *marker.LastSeen
+		}
+	} else if value != "" {
+		if since, err = time.Parse(time.RFC3339, value); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			ctx.Logger.WithError(err).Error("getMyStream: Invalid since date.")
+			return
+		}
+	}
+
+	// Call the database function to get the user's stream, in chronological order unless the ranked mode is requested.
+	var stream []database.CompletePhoto
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "", streamModeChronological:
+		stream, err = rt.db.GetMyStream(userID, since)
+	case streamModeRanked:
+		if r.URL.Query().Get("since") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			ctx.Logger.Error("getMyStream: The since date is not supported in ranked mode.")
+			return
+		}
+
+		// Ranked streams are paginated, as they are scored on each request.
+		limit, offset, pageErr := getPagination(r)
+		if pageErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			ctx.Logger.WithError(pageErr).Error("getMyStream: Invalid pagination.")
+			return
+		}
+		stream, err = rt.db.GetRankedStream(userID, rt.feedWeights, limit, offset)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.Errorf("getMyStream: Invalid mode %q.", mode)
+		return
+	}
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			// Return a NotFound status if the user does not exist.
@@ -315,7 +530,107 @@ func (rt *_router) getMyStream(w http.ResponseWriter, r *http.Request, ps httpro
 	}
 
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(stream)
+	_ = json.NewEncoder(w).Encode(Stream{Photos: stream, NewCount: marker.NewCount})
+}
+
+// getStreamMarker returns how far the user has seen their stream, with the number of photos uploaded since then.
+func (rt *_router) getStreamMarker(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getStreamMarker: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	dbMarker, err := rt.db.GetStreamMarker(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The user does not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("getStreamMarker: User not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getStreamMarker: Error fetching stream marker.")
+		return
+	}
+
+	var marker StreamMarker
+	marker.StreamMarkerFromDatabase(dbMarker)
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(marker)
+}
+
+// setStreamSeen marks the stream of the user as seen up to the specified photo, usually the most recent one shown.
+// The marker never moves back.
+func (rt *_router) setStreamSeen(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("setStreamSeen: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the last seen photo from the request body.
+	var body struct {
+		PhotoID int `json:"photoID"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("setStreamSeen: Invalid request.")
+		return
+	}
+	if body.PhotoID <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.Error("setStreamSeen: Invalid photo ID.")
+		return
+	}
+
+	dbMarker, err := rt.db.SetStreamSeen(userID, body.PhotoID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The photo is not in the stream of the user, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("setStreamSeen: Photo not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("setStreamSeen: Error updating stream marker.")
+		return
+	}
+
+	var marker StreamMarker
+	marker.StreamMarkerFromDatabase(dbMarker)
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(marker)
 }
 
 // getUsers searches for users whose usernames starts with a specified substring.
@@ -353,15 +668,15 @@ func (rt *_router) getUsers(w http.ResponseWriter, r *http.Request, ps httproute
 	_ = json.NewEncoder(w).Encode(users)
 }
 
-// getBanStatus checks if a user has been banned by the currently logged-in user.
-func (rt *_router) getBanStatus(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+// getSuggestions returns the users the specified user may want to follow, with the reason of each suggestion.
+func (rt *_router) getSuggestions(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
 	w.Header().Set("Content-Type", "application/json")
 
 	// Extract the ID of the user making the request.
 	userID, err := strconv.Atoi(ps.ByName("userid"))
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		ctx.Logger.WithError(err).Error("getBanStatus: Invalid user ID format.")
+		ctx.Logger.WithError(err).Error("getSuggestions: Invalid user ID format.")
 		return
 	}
 
@@ -373,22 +688,474 @@ func (rt *_router) getBanStatus(w http.ResponseWriter, r *http.Request, ps httpr
 		return
 	}
 
-	// Extract the ID of the user to check.
-	userToCheckID, err := strconv.Atoi(ps.ByName("banneduserid"))
+	// Extract the requested page.
+	limit, offset, err := getPagination(r)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		ctx.Logger.WithError(err).Error("getBanStatus: Invalid user to check ID format.")
+		ctx.Logger.WithError(err).Error("getSuggestions: Invalid pagination.")
 		return
 	}
 
-	// Call the database function to get the ban status.
-	isBanned, err := rt.db.GetBanStatus(userID, userToCheckID)
+	// Call the database function to rank the suggestions.
+	dbSuggestions, err := rt.db.GetSuggestions(userID, limit, offset)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		ctx.Logger.WithError(err).Error("getBanStatus: Error while checking ban status")
+		ctx.Logger.WithError(err).Error("getSuggestions: Error fetching suggestions.")
+		return
+	}
+
+	suggestions := make([]Suggestion, len(dbSuggestions))
+	for i, suggestion := range dbSuggestions {
+		suggestions[i].SuggestionFromDatabase(suggestion)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(suggestions)
+}
+
+// getRelationship returns how the user making the request and another user are related: follows in both directions,
+// bans in both directions, mute and pending follow requests.
+func (rt *_router) getRelationship(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getRelationship: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the ID of the other user.
+	otherID, err := strconv.Atoi(ps.ByName("otherid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getRelationship: Invalid other user ID format.")
+		return
+	}
+
+	dbRelationships, err := rt.db.GetRelationships(userID, []int{otherID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The other user does not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("getRelationship: User not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getRelationship: Error fetching relationship.")
+		return
+	}
+
+	var relationship Relationship
+	relationship.RelationshipFromDatabase(dbRelationships[0])
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(relationship)
+}
+
+// getRelationships returns the relationships between the user making the request and several other users, listed in
+// the "ids" query parameter. Users that do not exist are left out.
+func (rt *_router) getRelationships(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getRelationships: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the IDs of the other users.
+	otherIDs, err := parseIDList(r.URL.Query().Get("ids"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getRelationships: Invalid user IDs.")
+		return
+	}
+
+	dbRelationships, err := rt.db.GetRelationships(userID, otherIDs)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getRelationships: Error fetching relationships.")
+		return
+	}
+
+	relationships := make([]Relationship, len(dbRelationships))
+	for i, relationship := range dbRelationships {
+		relationships[i].RelationshipFromDatabase(relationship)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(relationships)
+}
+
+// getBanStatus checks if a user has been banned by the currently logged-in user.
+func (rt *_router) getBanStatus(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getBanStatus: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the ID of the user to check.
+	userToCheckID, err := strconv.Atoi(ps.ByName("banneduserid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getBanStatus: Invalid user to check ID format.")
+		return
+	}
+
+	// Call the database function to get the ban status.
+	isBanned, err := rt.db.GetBanStatus(userID, userToCheckID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getBanStatus: Error while checking ban status")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(isBanned)
+}
+
+// getBannedUsers returns the users banned by the specified user, with the ban details.
+func (rt *_router) getBannedUsers(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getBannedUsers: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the requested page.
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getBannedUsers: Invalid pagination.")
+		return
+	}
+
+	// Call the database function to get the banned users.
+	dbBans, err := rt.db.GetBannedUsers(userID, limit, offset)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getBannedUsers: Error fetching banned users.")
+		return
+	}
+
+	bans := make([]Ban, len(dbBans))
+	for i, ban := range dbBans {
+		bans[i].BanFromDatabase(ban)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(bans)
+}
+
+// muteUser adds a user to the specified user's muted list.
+func (rt *_router) muteUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("muteUser: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the user ID of the user to be muted from the request body.
+	var mutedUser User
+	if err := json.NewDecoder(r.Body).Decode(&mutedUser); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("muteUser: Invalid request.")
+		return
+	}
+
+	// Mute the user.
+	if err := rt.db.MuteUser(userID, mutedUser.UserID); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("muteUser: Error muting user in the database.")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// unmuteUser removes a user from the specified user's muted list.
+func (rt *_router) unmuteUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("unmuteUser: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the user ID of the muted user.
+	mutedUserID, err := strconv.Atoi(ps.ByName("muteduserid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("unmuteUser: Invalid muted user ID format.")
+		return
+	}
+
+	// Unmute the user.
+	if err := rt.db.UnmuteUser(userID, mutedUserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Return a NotFound status if the user was not muted.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("unmuteUser: Muted user not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("unmuteUser: Error unmuting user.")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// getMutedUsers returns the users muted by the specified user.
+func (rt *_router) getMutedUsers(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getMutedUsers: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the requested page.
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getMutedUsers: Invalid pagination.")
+		return
+	}
+
+	// Call the database function to get the muted users.
+	dbUsers, err := rt.db.GetMutedUsers(userID, limit, offset)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getMutedUsers: Error fetching muted users.")
+		return
+	}
+
+	users := make([]User, len(dbUsers))
+	for i, user := range dbUsers {
+		users[i].UserFromDatabase(user)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(users)
+}
+
+// getFollowRequests returns the users waiting for the specified user to approve their follow request.
+func (rt *_router) getFollowRequests(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getFollowRequests: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the requested page.
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getFollowRequests: Invalid pagination.")
+		return
+	}
+
+	// Call the database function to get the pending follow requests.
+	dbUsers, err := rt.db.GetFollowRequests(userID, limit, offset)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getFollowRequests: Error fetching follow requests.")
+		return
+	}
+
+	users := make([]User, len(dbUsers))
+	for i, user := range dbUsers {
+		users[i].UserFromDatabase(user)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(users)
+}
+
+// approveFollowRequest accepts a pending follow request sent to the specified user.
+func (rt *_router) approveFollowRequest(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("approveFollowRequest: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the ID of the user who sent the request.
+	requesterID, err := strconv.Atoi(ps.ByName("requesterid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("approveFollowRequest: Invalid requester ID format.")
+		return
+	}
+
+	// Approve the request, making the requester a follower.
+	if err := rt.db.ApproveFollowRequest(userID, requesterID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Return a NotFound status if there is no such request.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("approveFollowRequest: Follow request not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("approveFollowRequest: Error approving follow request.")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// rejectFollowRequest removes a pending follow request sent to the specified user.
+func (rt *_router) rejectFollowRequest(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("rejectFollowRequest: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the ID of the user who sent the request.
+	requesterID, err := strconv.Atoi(ps.ByName("requesterid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("rejectFollowRequest: Invalid requester ID format.")
+		return
+	}
+
+	// Reject the request.
+	if err := rt.db.RejectFollowRequest(userID, requesterID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Return a NotFound status if there is no such request.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("rejectFollowRequest: Follow request not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("rejectFollowRequest: Error rejecting follow request.")
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(isBanned)
 }
@@ -1,9 +1,24 @@
 package api
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/mail"
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
+
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/database"
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/webhooks"
+	"golang.org/x/text/unicode/norm"
 )
 
 // --- AUTHENTICATION FUNCTIONS ---
@@ -39,10 +54,45 @@ func isUserLoggedIn(bearerToken string) bool {
 	return bearerToken != ""
 }
 
+// validateRole checks if the user making the request has one of the specified roles, and has not been suspended.
+// It returns the ID of the user, along with the authorization status.
+func (rt *_router) validateRole(bearerToken string, roles ...string) (int, int) {
+	if !isUserLoggedIn(bearerToken) {
+		// The user is not authenticated.
+		return 0, http.StatusForbidden
+	}
+
+	userID, err := strconv.Atoi(bearerToken)
+	if err != nil {
+		return 0, http.StatusUnauthorized
+	}
+
+	role, err := rt.db.GetUserRole(userID)
+	if err != nil {
+		return 0, http.StatusForbidden
+	}
+
+	// Suspended moderators and admins lose their privileges.
+	if suspended, err := rt.db.IsUserSuspended(userID); err != nil || suspended {
+		return 0, http.StatusForbidden
+	}
+
+	for _, allowed := range roles {
+		if role == allowed {
+			// The user is authorized.
+			return userID, http.StatusOK
+		}
+	}
+
+	// The user does not have the required role.
+	return 0, http.StatusForbidden
+}
+
 // --- USERNAME VALIDATION ---
 
-// isValidUsername checks if the username meets the requirements defined in the OpenAPI specification.
-func isValidUsername(username string) bool {
+// IsValidUsername checks if the username meets the requirements defined in the OpenAPI specification. It is also used
+// to check the username of the admin designated at startup.
+func IsValidUsername(username string) bool {
 	// Check if the length of the username is within the specified range.
 	if len(username) < 3 || len(username) > 16 {
 		return false
@@ -53,6 +103,326 @@ func isValidUsername(username string) bool {
 	return match
 }
 
+// --- EMAIL VALIDATION ---
+
+// maxEmailLength is the maximum length of an email address.
+const maxEmailLength = 254
+
+// digestFrequencies lists how often the email digests can be sent.
+var digestFrequencies = map[string]bool{
+	database.DigestOff:    true,
+	database.DigestDaily:  true,
+	database.DigestWeekly: true,
+}
+
+// isValidEmail checks if the string is a bare email address, such as "alice@example.com".
+func isValidEmail(email string) bool {
+	if len(email) > maxEmailLength {
+		return false
+	}
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email
+}
+
+// --- COMMENT VALIDATION ---
+
+const (
+	// Comment length bounds, in characters, as defined in the OpenAPI specification.
+	minCommentLength = 3
+	maxCommentLength = 16
+
+	// maxCommentRequestSize is the maximum size of a comment request body, in bytes.
+	maxCommentRequestSize = 64 * 1024
+)
+
+// Reason codes returned to the client when a comment is rejected.
+const (
+	reasonCommentTooShort        = "COMMENT_TOO_SHORT"
+	reasonCommentTooLong         = "COMMENT_TOO_LONG"
+	reasonCommentInvalidEncoding = "COMMENT_INVALID_ENCODING"
+	reasonCommentBlocked         = "COMMENT_BLOCKED"
+)
+
+// commentRejectionMessages describes each rejection reason code.
+var commentRejectionMessages = map[string]string{
+	reasonCommentTooShort:        "the comment is too short",
+	reasonCommentTooLong:         "the comment is too long",
+	reasonCommentInvalidEncoding: "the comment is not valid UTF-8 text",
+	reasonCommentBlocked:         "the comment contains blocked content",
+}
+
+// commentFilter rejects comments matching a blocklist of words or regular expressions.
+type commentFilter struct {
+	blocklist []*regexp.Regexp
+}
+
+// newCommentFilter compiles the blocked words and patterns into a commentFilter.
+// Words are normalized to Unicode NFC like the comments, and matched as whole words with Unicode case folding; the
+// word boundaries are any character other than a letter, a mark or a digit, so that non-ASCII words match too.
+// Patterns are used as they are.
+func newCommentFilter(blockedWords, blockedPatterns []string) (commentFilter, error) {
+	var f commentFilter
+	for _, word := range blockedWords {
+		word = strings.TrimSpace(norm.NFC.String(word))
+		if word == "" {
+			continue
+		}
+		f.blocklist = append(f.blocklist, regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{M}\p{N}])`+regexp.QuoteMeta(word)+`(?:$|[^\p{L}\p{M}\p{N}])`))
+	}
+	for _, pattern := range blockedPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return f, fmt.Errorf("invalid blocked comment pattern %q: %w", pattern, err)
+		}
+		f.blocklist = append(f.blocklist, re)
+	}
+	return f, nil
+}
+
+// check normalizes the comment text (Unicode NFC, surrounding spaces trimmed) and validates it.
+// It returns the normalized text, and a non-empty reason code if the comment must be rejected.
+func (f commentFilter) check(text string) (string, string) {
+	if !utf8.ValidString(text) {
+		return text, reasonCommentInvalidEncoding
+	}
+
+	text = strings.TrimSpace(norm.NFC.String(text))
+
+	length := utf8.RuneCountInString(text)
+	if length < minCommentLength {
+		return text, reasonCommentTooShort
+	}
+	if length > maxCommentLength {
+		return text, reasonCommentTooLong
+	}
+
+	for _, re := range f.blocklist {
+		if re.MatchString(text) {
+			return text, reasonCommentBlocked
+		}
+	}
+
+	return text, ""
+}
+
+// readComment decodes a comment from the request body, normalizing and validating its text.
+// A non-empty reason code is returned if the comment is well-formed but not acceptable.
+func (rt *_router) readComment(r *http.Request) (Comment, string, error) {
+	var comment Comment
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCommentRequestSize+1))
+	if err != nil {
+		return comment, "", err
+	}
+	if len(body) > maxCommentRequestSize {
+		return comment, reasonCommentTooLong, nil
+	}
+
+	if err := json.Unmarshal(body, &comment); err != nil {
+		return comment, "", err
+	}
+
+	var reason string
+	comment.CommentText, reason = rt.commentFilter.check(comment.CommentText)
+	return comment, reason, nil
+}
+
+// rejectComment replies with an Unprocessable Entity status, describing why the comment was rejected.
+func rejectComment(w http.ResponseWriter, reason string) {
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	_ = json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+		Reason  string `json:"reason"`
+	}{
+		Message: commentRejectionMessages[reason],
+		Reason:  reason,
+	})
+}
+
+// --- BAN VALIDATION ---
+
+// maxBanReasonLength is the maximum length, in characters, of the private reason attached to a ban.
+const maxBanReasonLength = 500
+
+// --- REPORT VALIDATION ---
+
+// maxReportDetailsLength is the maximum length, in characters, of the free text attached to a report.
+const maxReportDetailsLength = 1000
+
+// reportReasons lists the reasons a user can choose from when reporting content.
+var reportReasons = map[string]bool{
+	"spam":       true,
+	"harassment": true,
+	"hate":       true,
+	"nudity":     true,
+	"violence":   true,
+	"other":      true,
+}
+
+// readReport decodes a report from the request body, checking its reason and details.
+func readReport(r *http.Request) (Report, error) {
+	var report Report
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		return report, err
+	}
+
+	if !reportReasons[report.Reason] {
+		return report, fmt.Errorf("invalid report reason %q", report.Reason)
+	}
+
+	report.Details = strings.TrimSpace(report.Details)
+	if utf8.RuneCountInString(report.Details) > maxReportDetailsLength {
+		return report, errors.New("report details too long")
+	}
+
+	report.CreatedAt = time.Now()
+	return report, nil
+}
+
+// --- NOTIFICATION PREFERENCES VALIDATION ---
+
+// notificationPreferenceEvents lists the kinds of events the users can set their notification preferences for.
+var notificationPreferenceEvents = map[string]bool{
+	database.PreferenceLikes:    true,
+	database.PreferenceComments: true,
+	database.PreferenceFollows:  true,
+	database.PreferenceMentions: true,
+}
+
+// --- WEBHOOK VALIDATION ---
+
+// webhookEvents lists the events a webhook can subscribe to.
+var webhookEvents = map[string]bool{
+	database.WebhookEventPhotoCreated:    true,
+	database.WebhookEventFollowerCreated: true,
+	database.WebhookEventReportCreated:   true,
+}
+
+// readWebhook decodes a webhook from the request body, checking its URL and events, and generates its secret.
+func readWebhook(r *http.Request) (Webhook, error) {
+	var webhook Webhook
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		return webhook, err
+	}
+
+	target, err := url.Parse(webhook.URL)
+	if err != nil {
+		return webhook, fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	if (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return webhook, fmt.Errorf("invalid webhook URL %q", webhook.URL)
+	}
+
+	// Webhooks cannot reach the internal network of the server.
+	if err := webhooks.CheckURL(r.Context(), webhook.URL); err != nil {
+		return webhook, err
+	}
+
+	if len(webhook.Events) == 0 {
+		return webhook, errors.New("no webhook events")
+	}
+	seen := make(map[string]bool)
+	events := webhook.Events[:0]
+	for _, event := range webhook.Events {
+		if !webhookEvents[event] {
+			return webhook, fmt.Errorf("invalid webhook event %q", event)
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	webhook.Events = events
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return webhook, fmt.Errorf("error generating webhook secret: %w", err)
+	}
+	webhook.Secret = hex.EncodeToString(secret)
+
+	webhook.CreatedAt = time.Now()
+	return webhook, nil
+}
+
+// --- COLLECTION VALIDATION ---
+
+// maxCollectionNameLength is the maximum length of a collection name, in characters.
+const maxCollectionNameLength = 50
+
+// readCollection decodes a collection from the request body, checking its name. Surrounding spaces are removed from
+// the name.
+func readCollection(r *http.Request) (Collection, error) {
+	var collection Collection
+	if err := json.NewDecoder(r.Body).Decode(&collection); err != nil {
+		return collection, err
+	}
+
+	collection.Name = strings.TrimSpace(collection.Name)
+	if collection.Name == "" || !utf8.ValidString(collection.Name) || utf8.RuneCountInString(collection.Name) > maxCollectionNameLength {
+		return collection, fmt.Errorf("invalid collection name %q", collection.Name)
+	}
+
+	collection.CreatedAt = time.Now()
+	return collection, nil
+}
+
+// --- PAGINATION ---
+
+const (
+	// defaultPageSize is the number of items returned when the client does not specify a limit.
+	defaultPageSize = 20
+
+	// maxPageSize is the maximum number of items returned in a single page.
+	maxPageSize = 100
+)
+
+// getPagination extracts the "limit" and "offset" query parameters, applying defaults when they are missing.
+func getPagination(r *http.Request) (int, int, error) {
+	limit, offset := defaultPageSize, 0
+
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return 0, 0, fmt.Errorf("invalid limit %q", value)
+		}
+	}
+
+	if value := r.URL.Query().Get("offset"); value != "" {
+		var err error
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid offset %q", value)
+		}
+	}
+
+	return limit, offset, nil
+}
+
+// --- ID LISTS ---
+
+// parseIDList parses a comma-separated list of IDs, such as "3,7,12". At most maxPageSize IDs are accepted.
+func parseIDList(value string) ([]int, error) {
+	if value == "" {
+		return nil, errors.New("empty ID list")
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) > maxPageSize {
+		return nil, fmt.Errorf("too many IDs, the maximum is %d", maxPageSize)
+	}
+
+	ids := make([]int, len(parts))
+	for i, part := range parts {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID %q", part)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
 // --- PHOTO FORMAT VALIDATION ---
 
 // CheckImageType checks if the content is of type PNG or JPG.
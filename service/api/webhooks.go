@@ -0,0 +1,209 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/api/reqcontext"
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/database"
+	"github.com/julienschmidt/httprouter"
+)
+
+// createWebhook registers a webhook of the specified user. The secret used to sign the payloads is only returned here.
+func (rt *_router) createWebhook(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("createWebhook: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the URL and the events from the request body.
+	webhook, err := readWebhook(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("createWebhook: Invalid request.")
+		return
+	}
+	webhook.OwnerID = userID
+
+	// Reports are only delivered to admins.
+	for _, event := range webhook.Events {
+		if event != database.WebhookEventReportCreated {
+			continue
+		}
+		if _, roleStatus := rt.validateRole(bearerToken, database.RoleAdmin); roleStatus != http.StatusOK {
+			w.WriteHeader(roleStatus)
+			ctx.Logger.Error("createWebhook: Only admins can subscribe to reports.")
+			return
+		}
+	}
+
+	dbWebhook, err := rt.db.CreateWebhook(webhook.WebhookToDatabase())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("createWebhook: Error creating webhook.")
+		return
+	}
+
+	webhook.WebhookFromDatabase(dbWebhook)
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(webhook)
+}
+
+// getWebhooks returns the webhooks registered by the specified user, without their secrets.
+func (rt *_router) getWebhooks(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getWebhooks: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	dbWebhooks, err := rt.db.GetWebhooks(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getWebhooks: Error fetching webhooks.")
+		return
+	}
+
+	webhooks := make([]Webhook, len(dbWebhooks))
+	for i, webhook := range dbWebhooks {
+		webhooks[i].WebhookFromDatabase(webhook)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(webhooks)
+}
+
+// deleteWebhook removes a webhook of the specified user. Pending deliveries are discarded.
+func (rt *_router) deleteWebhook(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("deleteWebhook: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the webhook ID from the path parameters.
+	webhookID, err := strconv.Atoi(ps.ByName("webhookid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("deleteWebhook: Invalid webhook ID format.")
+		return
+	}
+
+	err = rt.db.DeleteWebhook(userID, webhookID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The webhook does not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("deleteWebhook: Webhook not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("deleteWebhook: Error removing webhook.")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getWebhookDeliveries returns the delivery log of a webhook of the specified user, most recent first.
+func (rt *_router) getWebhookDeliveries(w http.ResponseWriter, r *http.Request, ps httprouter.Params, ctx reqcontext.RequestContext) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the ID of the user making the request.
+	userID, err := strconv.Atoi(ps.ByName("userid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getWebhookDeliveries: Invalid user ID format.")
+		return
+	}
+
+	// Authorization
+	bearerToken := extractBearer(r.Header.Get("Authorization"))
+	authorizationStatus := validateRequestingUser(strconv.Itoa(userID), bearerToken)
+	if authorizationStatus != http.StatusOK {
+		w.WriteHeader(authorizationStatus)
+		return
+	}
+
+	// Extract the webhook ID from the path parameters.
+	webhookID, err := strconv.Atoi(ps.ByName("webhookid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getWebhookDeliveries: Invalid webhook ID format.")
+		return
+	}
+
+	// Extract the requested page.
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getWebhookDeliveries: Invalid pagination.")
+		return
+	}
+
+	dbDeliveries, err := rt.db.GetWebhookDeliveries(userID, webhookID, limit, offset)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The webhook does not exist, return a NotFound status.
+			w.WriteHeader(http.StatusNotFound)
+			ctx.Logger.WithError(err).Error("getWebhookDeliveries: Webhook not found.")
+			return
+		}
+
+		// Other errors.
+		w.WriteHeader(http.StatusBadRequest)
+		ctx.Logger.WithError(err).Error("getWebhookDeliveries: Error fetching deliveries.")
+		return
+	}
+
+	deliveries := make([]WebhookDelivery, len(dbDeliveries))
+	for i, delivery := range dbDeliveries {
+		deliveries[i].WebhookDeliveryFromDatabase(delivery)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(deliveries)
+}
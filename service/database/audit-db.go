@@ -0,0 +1,118 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Actions recorded in the audit log.
+const (
+	AuditSessionCreate  = "session-create"
+	AuditUsernameChange = "username-change"
+	AuditBan            = "ban"
+	AuditUnban          = "unban"
+	AuditPhotoDelete    = "photo-delete"
+	AuditCommentDelete  = "comment-delete"
+	AuditReportDecision = "report-decision"
+	AuditUserSuspend    = "user-suspend"
+	AuditUserUnsuspend  = "user-unsuspend"
+	AuditRoleChange     = "role-change"
+)
+
+// Types of the targets of the audited actions.
+const (
+	AuditTargetUser    = "user"
+	AuditTargetPhoto   = "photo"
+	AuditTargetComment = "comment"
+	AuditTargetReport  = "report"
+)
+
+// ForRequest returns a copy of the database handle that records the given request ID in the audit log.
+func (db *appdbimpl) ForRequest(requestID string) AppDatabase {
+	return &appdbimpl{
+		c:              db.c,
+		conn:           db.conn,
+		requestID:      requestID,
+		timelineLength: db.timelineLength,
+	}
+}
+
+// logAuditEvent appends an event to the audit log. actorID is 0 for actions taken by the system.
+func (db *appdbimpl) logAuditEvent(actorID int, action, targetType string, targetID int, details string) error {
+	actor := sql.NullInt64{Int64: int64(actorID), Valid: actorID != 0}
+	requestID := sql.NullString{String: db.requestID, Valid: db.requestID != ""}
+	_, err := db.c.Exec("INSERT INTO audit_events (actorid, action, targetType, targetid, details, requestid, createdAt) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		actor, action, targetType, targetID, details, requestID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("error recording audit event: %w", err)
+	}
+	return nil
+}
+
+// GetAuditEvents returns a page of the audit events matching the filter, most recent first.
+func (db *appdbimpl) GetAuditEvents(f AuditFilter) ([]AuditEvent, error) {
+	var events []AuditEvent
+
+	// Build the WHERE clause from the filters that are set.
+	var conditions []string
+	var args []interface{}
+	if f.ActorID != 0 {
+		conditions = append(conditions, "actorid = ?")
+		args = append(args, f.ActorID)
+	}
+	if f.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, f.Action)
+	}
+	if f.TargetType != "" {
+		conditions = append(conditions, "targetType = ?")
+		args = append(args, f.TargetType)
+	}
+	if f.TargetID != 0 {
+		conditions = append(conditions, "targetid = ?")
+		args = append(args, f.TargetID)
+	}
+	if !f.Since.IsZero() {
+		conditions = append(conditions, "createdAt >= ?")
+		args = append(args, f.Since.UTC())
+	}
+	if !f.Until.IsZero() {
+		conditions = append(conditions, "createdAt < ?")
+		args = append(args, f.Until.UTC())
+	}
+
+	query := "SELECT eventid, actorid, action, targetType, targetid, details, requestid, createdAt FROM audit_events"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY createdAt DESC, eventid DESC LIMIT ? OFFSET ?"
+	args = append(args, f.Limit, f.Offset)
+
+	rows, err := db.c.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching audit events: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	// Iterate over the rows to extract each event's data.
+	for rows.Next() {
+		var event AuditEvent
+		var actorID sql.NullInt64
+		var details, requestID sql.NullString
+		if err := rows.Scan(&event.EventID, &actorID, &event.Action, &event.TargetType, &event.TargetID, &details, &requestID, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning audit event row: %w", err)
+		}
+		event.ActorID = int(actorID.Int64)
+		event.Details = details.String
+		event.RequestID = requestID.String
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over audit event rows: %w", err)
+	}
+
+	return events, nil
+}
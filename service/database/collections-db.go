@@ -0,0 +1,247 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxCollectionsPerUser is the maximum number of collections a user can create.
+const maxCollectionsPerUser = 100
+
+// visibleItemCondition is the SQL condition hiding the saved photos, identified by the `p` alias, that the owner of the
+// collection can no longer see: photos of users who banned, or were banned by, the owner, of suspended users, and of
+// private accounts the owner stopped following. The items are kept, so that they show up again if the photos become
+// visible. The owner ID must be bound four times.
+var visibleItemCondition = notBannedCondition("p.userid") + " AND " + notSuspendedCondition("p.userid") + " AND " + visibleAccountCondition("p.userid")
+
+// CreateCollection creates a collection. Names are unique among the collections of the same user.
+func (db *appdbimpl) CreateCollection(c Collection) (Collection, error) {
+	var count int
+	err := db.c.QueryRow("SELECT COUNT(*) FROM collections WHERE ownerid = ?", c.OwnerID).Scan(&count)
+	if err != nil {
+		return c, fmt.Errorf("error counting collections: %w", err)
+	}
+	if count >= maxCollectionsPerUser {
+		return c, fmt.Errorf("cannot create more than %d collections", maxCollectionsPerUser)
+	}
+
+	if err := db.checkCollectionName(c.OwnerID, 0, c.Name); err != nil {
+		return c, err
+	}
+
+	result, err := db.c.Exec("INSERT INTO collections (ownerid, name, createdAt) VALUES (?, ?, ?)", c.OwnerID, c.Name, c.CreatedAt)
+	if err != nil {
+		return c, fmt.Errorf("error inserting collection into database: %w", err)
+	}
+
+	// Get the ID of the newly created collection.
+	collectionID, err := result.LastInsertId()
+	if err != nil {
+		return c, err
+	}
+	c.CollectionID = int(collectionID)
+	c.PhotosCount = 0
+
+	return c, nil
+}
+
+// GetCollections returns the collections of the specified user, with the number of photos they can still see in each.
+func (db *appdbimpl) GetCollections(ownerID int) ([]Collection, error) {
+	var collections []Collection
+
+	rows, err := db.c.Query(`SELECT c.collectionid, c.ownerid, c.name, c.createdAt,
+			(SELECT COUNT(*) FROM collection_items i JOIN photos p ON i.photoid = p.photoid
+				WHERE i.collectionid = c.collectionid AND `+visibleItemCondition+`) AS photosCount
+		FROM collections c WHERE c.ownerid = ? ORDER BY c.collectionid`,
+		ownerID, ownerID, ownerID, ownerID, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching collections: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	// Iterate over the rows to extract each collection's data.
+	for rows.Next() {
+		var c Collection
+		if err := rows.Scan(&c.CollectionID, &c.OwnerID, &c.Name, &c.CreatedAt, &c.PhotosCount); err != nil {
+			return nil, fmt.Errorf("error scanning collection row: %w", err)
+		}
+		collections = append(collections, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over collection rows: %w", err)
+	}
+
+	return collections, nil
+}
+
+// RenameCollection renames a collection of the specified user.
+func (db *appdbimpl) RenameCollection(ownerID, collectionID int, name string) error {
+	if err := db.checkCollectionOwner(ownerID, collectionID); err != nil {
+		return err
+	}
+
+	if err := db.checkCollectionName(ownerID, collectionID, name); err != nil {
+		return err
+	}
+
+	_, err := db.c.Exec("UPDATE collections SET name = ? WHERE collectionid = ?", name, collectionID)
+	if err != nil {
+		return fmt.Errorf("error renaming collection: %w", err)
+	}
+	return nil
+}
+
+// DeleteCollection removes a collection of the specified user, with its items. The photos are not affected.
+func (db *appdbimpl) DeleteCollection(ownerID, collectionID int) error {
+	result, err := db.c.Exec("DELETE FROM collections WHERE collectionid = ? AND ownerid = ?", collectionID, ownerID)
+	if err != nil {
+		return fmt.Errorf("error removing collection: %w", err)
+	}
+
+	// Check if the collection actually existed.
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error removing collection: %w", err)
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = db.c.Exec("DELETE FROM collection_items WHERE collectionid = ?", collectionID)
+	if err != nil {
+		return fmt.Errorf("error removing collection items: %w", err)
+	}
+
+	return nil
+}
+
+// AddToCollection saves a photo to a collection of the specified user. Only the photos the user can see can be saved,
+// and saving a photo already in the collection has no effect.
+func (db *appdbimpl) AddToCollection(ownerID, collectionID, photoID int, addedAt time.Time) error {
+	if err := db.checkCollectionOwner(ownerID, collectionID); err != nil {
+		return err
+	}
+
+	// Get the ID of the user who posted the photo.
+	authorID, err := db.GetPhotoUserID(photoID)
+	if err != nil {
+		return err
+	}
+
+	// The photos of suspended users are reported as not found.
+	if err := db.checkSuspended(authorID); errors.Is(err, ErrUserSuspended) {
+		return sql.ErrNoRows
+	} else if err != nil {
+		return err
+	}
+
+	// Check if there is a ban between the collector and the user who posted the photo.
+	if err := db.checkBan(ownerID, authorID); err != nil {
+		return fmt.Errorf("cannot save this photo: %w", err)
+	}
+
+	// Only approved followers can save the photos of private accounts.
+	if err := db.checkPrivacy(ownerID, authorID); err != nil {
+		return fmt.Errorf("cannot save this photo: %w", err)
+	}
+
+	_, err = db.c.Exec("INSERT OR IGNORE INTO collection_items (collectionid, photoid, addedAt) VALUES (?, ?, ?)",
+		collectionID, photoID, addedAt)
+	if err != nil {
+		return fmt.Errorf("error saving photo to collection: %w", err)
+	}
+	return nil
+}
+
+// RemoveFromCollection removes a photo from a collection of the specified user.
+func (db *appdbimpl) RemoveFromCollection(ownerID, collectionID, photoID int) error {
+	if err := db.checkCollectionOwner(ownerID, collectionID); err != nil {
+		return err
+	}
+
+	result, err := db.c.Exec("DELETE FROM collection_items WHERE collectionid = ? AND photoid = ?", collectionID, photoID)
+	if err != nil {
+		return fmt.Errorf("error removing photo from collection: %w", err)
+	}
+
+	// Check if the photo was actually in the collection.
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error removing photo from collection: %w", err)
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetCollectionPhotos returns a page of the photos of a collection of the specified user, most recently saved first.
+// Photos the user can no longer see are left out.
+func (db *appdbimpl) GetCollectionPhotos(ownerID, collectionID, limit, offset int) ([]CompletePhoto, error) {
+	if err := db.checkCollectionOwner(ownerID, collectionID); err != nil {
+		return nil, err
+	}
+
+	var photos []CompletePhoto
+
+	rows, err := db.c.Query(`SELECT p.photoid, p.userid, p.username, p.imageData, p.uploadDate, p.likesCount, p.commentsCount, p.commentsEnabled
+		FROM collection_items i JOIN photos p ON i.photoid = p.photoid
+		WHERE i.collectionid = ? AND `+visibleItemCondition+`
+		ORDER BY i.addedAt DESC, i.photoid DESC LIMIT ? OFFSET ?`,
+		collectionID, ownerID, ownerID, ownerID, ownerID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching collection photos: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	for rows.Next() {
+		var photo CompletePhoto
+		if err := rows.Scan(&photo.PhotoID, &photo.UserID, &photo.Username, &photo.ImageData, &photo.UploadDate, &photo.LikesCount, &photo.CommentsCount, &photo.CommentsEnabled); err != nil {
+			return nil, fmt.Errorf("error scanning collection photo row: %w", err)
+		}
+		photos = append(photos, photo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over collection photo rows: %w", err)
+	}
+
+	// Retrieve the likes and comments of each photo, once the rows are closed.
+	for i := range photos {
+		if err := db.getPhotoDetails(ownerID, &photos[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return photos, nil
+}
+
+// checkCollectionOwner returns sql.ErrNoRows if the collection does not exist or does not belong to the user.
+func (db *appdbimpl) checkCollectionOwner(ownerID, collectionID int) error {
+	var existingCollection int
+	err := db.c.QueryRow("SELECT 1 FROM collections WHERE collectionid = ? AND ownerid = ?", collectionID, ownerID).Scan(&existingCollection)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sql.ErrNoRows // Collection not found
+	} else if err != nil {
+		return fmt.Errorf("error checking existing collection: %w", err)
+	}
+	return nil
+}
+
+// checkCollectionName returns an error if the user has another collection with the same name. collectionID is the
+// collection being renamed, or 0 for a new one.
+func (db *appdbimpl) checkCollectionName(ownerID, collectionID int, name string) error {
+	var existingCollection int
+	err := db.c.QueryRow("SELECT 1 FROM collections WHERE ownerid = ? AND name = ? AND collectionid != ?",
+		ownerID, name, collectionID).Scan(&existingCollection)
+	if err == nil {
+		return fmt.Errorf("a collection named %q already exists", name)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("error checking collection name: %w", err)
+	}
+	return nil
+}
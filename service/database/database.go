@@ -34,39 +34,119 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 )
 
 // AppDatabase is the high level interface for the DB
 type AppDatabase interface {
+	// ForRequest returns a copy of the AppDatabase that records the given request ID in the audit log. The API uses it
+	// for the actions that are audited.
+	ForRequest(string) AppDatabase
+
 	CreateUser(User) (User, error)
 	UpdateUsername(int, string) error
 	CreatePhoto(Photo) (Photo, error)
-	FollowUser(int, int) error
+	FollowUser(int, int) (bool, error)
 	UnfollowUser(int, int) error
-	BanUser(int, int) error
+	BanUser(int, int, Ban) error
 	UnbanUser(int, int) error
 	LikePhoto(int, int, Like) error
 	UnlikePhoto(int, int, int) error
 	CommentPhoto(int, int, string, Comment) (Comment, error)
 	UncommentPhoto(int, int, int) error
+	EditComment(int, int, int, Comment) (Comment, error)
+	GetCommentRevisions(int, int, int) ([]CommentRevision, error)
+	SetCommentHidden(int, int, int, bool) error
+	SetCommentsEnabled(int, int, bool) error
+	GetPhoto(int, int, int) (CompletePhoto, error)
+	GetPhotoLikes(int, int, int, int, int) ([]Like, error)
+	GetPhotoComments(int, int, int, int, int) ([]Comment, error)
 	DeletePhoto(int, int) error
 	GetUserProfile(int, int) (Profile, error)
-	GetMyStream(int) ([]CompletePhoto, error)
+	GetMyStream(int, time.Time) ([]CompletePhoto, error)
+	GetStreamMarker(int) (StreamMarker, error)
+	SetStreamSeen(int, int) (StreamMarker, error)
+	UseTimelines(int) error
+	GetRankedStream(int, FeedWeights, int, int) ([]CompletePhoto, error)
+	GetTrendingPhotos(time.Duration, int) ([]TrendingPhoto, error)
+	GetExplorePhotos(int, []TrendingPhoto, int, int) ([]CompletePhoto, error)
 	GetUsers(int, string) ([]User, error)
+	GetSuggestions(int, int, int) ([]Suggestion, error)
 	GetBanStatus(int, int) (bool, error)
+	GetRelationships(int, []int) ([]Relationship, error)
+	GetMutualFollowers(int, int, int, int) ([]User, int, error)
+	GetBannedUsers(int, int, int) ([]Ban, error)
+	MuteUser(int, int) error
+	UnmuteUser(int, int) error
+	GetMutedUsers(int, int, int) ([]User, error)
+	HasMuted(int, int) (bool, error)
+	SetPrivate(int, bool) error
+	SetEmail(int, string) error
+	SetEmailDigest(int, string) error
+	GetFollowRequests(int, int, int) ([]User, error)
+	ApproveFollowRequest(int, int) error
+	RejectFollowRequest(int, int) error
+	GetUserRole(int) (string, error)
+	SetUserRole(int, int, string) error
+	SetUserSuspended(int, int, bool) error
+	IsUserSuspended(int) (bool, error)
+	BootstrapAdmin(string) (User, error)
+	GetAuditEvents(AuditFilter) ([]AuditEvent, error)
+	GetNotifications(int, int, int) ([]Notification, error)
+	GetUnreadNotificationsCount(int) (int, error)
+	SetNotificationRead(int, int, bool) error
+	MarkAllNotificationsRead(int) error
+	GetDueDigests(time.Time) ([]Digest, error)
+	GetNotificationPreferences(int) (NotificationPreferences, error)
+	SetNotificationPreferences(int, NotificationPreferences) error
+	GetNotificationChannels(int, string) (NotificationChannels, error)
+	SetDigestSent(int, time.Time) error
+	CreateCollection(Collection) (Collection, error)
+	GetCollections(int) ([]Collection, error)
+	RenameCollection(int, int, string) error
+	DeleteCollection(int, int) error
+	AddToCollection(int, int, int, time.Time) error
+	RemoveFromCollection(int, int, int) error
+	GetCollectionPhotos(int, int, int, int) ([]CompletePhoto, error)
+	CreateWebhook(Webhook) (Webhook, error)
+	GetWebhooks(int) ([]Webhook, error)
+	DeleteWebhook(int, int) error
+	GetWebhookDeliveries(int, int, int, int) ([]WebhookDelivery, error)
+	GetDueWebhookDeliveries(int) ([]WebhookDelivery, error)
+	UpdateWebhookDelivery(WebhookDelivery) error
+	CreateReport(Report) (Report, error)
+	GetReports(string, int, int) ([]Report, error)
+	ResolveReport(int, int, string, string) (Report, error)
 
 	// utils
 	GetPhotoUserID(int) (int, error)
 	GetUserDetails(int) (User, error)
 	GetFollowers(int) ([]User, error)
+	GetUnmutedFollowers(int) ([]User, error)
 	GetFollowing(int) ([]User, error)
-	GetUploadedPhotos(int) ([]CompletePhoto, error)
+	GetUploadedPhotos(int, int) ([]CompletePhoto, error)
 
 	Ping() error
 }
 
+// dbconn holds the methods shared by *sql.DB and *sql.Tx, so that the same queries can run inside a transaction.
+type dbconn interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 type appdbimpl struct {
-	c *sql.DB
+	c dbconn
+
+	// conn is the connection pool, used to begin transactions.
+	conn *sql.DB
+
+	// requestID is the ID of the request being served, recorded in the audit log. Empty outside requests.
+	requestID string
+
+	// timelineLength is the number of photos kept in each timeline with fan-out-on-write. Zero if disabled.
+	timelineLength int
 }
 
 // New returns a new instance of AppDatabase based on the SQLite connection `db`.
@@ -88,10 +168,49 @@ func New(db *sql.DB) (AppDatabase, error) {
 	}
 
 	return &appdbimpl{
-		c: db,
+		c:    db,
+		conn: db,
 	}, nil
 }
 
+// NewReadOnly returns an AppDatabase reading the existing database of the SQLite connection `db`, which may be opened
+// in read-only mode: unlike New, it never creates the database structure.
+func NewReadOnly(db *sql.DB) (AppDatabase, error) {
+	if db == nil {
+		return nil, errors.New("database is required when building a AppDatabase")
+	}
+
+	return &appdbimpl{
+		c:    db,
+		conn: db,
+	}, nil
+}
+
+// withTx runs f on a copy of the database handle whose queries are part of a single transaction, committed if f
+// succeeds and rolled back otherwise. Calls made inside f join the same transaction.
+func (db *appdbimpl) withTx(f func(tx *appdbimpl) error) error {
+	if _, ok := db.c.(*sql.Tx); ok {
+		return f(db)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+
+	txdb := *db
+	txdb.c = tx
+	if err := f(&txdb); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
 func createTables(db *sql.DB) error {
 	_, err := db.Exec("PRAGMA foreign_key=ON;")
 	if err != nil {
@@ -187,9 +306,320 @@ func createTables(db *sql.DB) error {
 		return fmt.Errorf("error creating comments structure: %w", err)
 	}
 
+	// Columns added after the first release; databases created by previous versions lack them.
+	err = addColumnIfMissing(db, "comments", "editedAt", "DATETIME")
+	if err != nil {
+		return fmt.Errorf("error updating comments structure: %w", err)
+	}
+	err = addColumnIfMissing(db, "likes", "createdAt", "DATETIME")
+	if err != nil {
+		return fmt.Errorf("error updating likes structure: %w", err)
+	}
+	err = addColumnIfMissing(db, "comments", "hidden", "INTEGER NOT NULL DEFAULT 0")
+	if err != nil {
+		return fmt.Errorf("error updating comments structure: %w", err)
+	}
+	err = addColumnIfMissing(db, "photos", "commentsEnabled", "INTEGER NOT NULL DEFAULT 1")
+	if err != nil {
+		return fmt.Errorf("error updating photos structure: %w", err)
+	}
+	err = addColumnIfMissing(db, "banned_users", "createdAt", "DATETIME")
+	if err != nil {
+		return fmt.Errorf("error updating ban structure: %w", err)
+	}
+	err = addColumnIfMissing(db, "banned_users", "reason", "TEXT")
+	if err != nil {
+		return fmt.Errorf("error updating ban structure: %w", err)
+	}
+	err = addColumnIfMissing(db, "banned_users", "expiresAt", "DATETIME")
+	if err != nil {
+		return fmt.Errorf("error updating ban structure: %w", err)
+	}
+
+	err = addColumnIfMissing(db, "users", "private", "INTEGER NOT NULL DEFAULT 0")
+	if err != nil {
+		return fmt.Errorf("error updating users structure: %w", err)
+	}
+
+	err = addColumnIfMissing(db, "users", "role", "TEXT NOT NULL DEFAULT 'user'")
+	if err != nil {
+		return fmt.Errorf("error updating users structure: %w", err)
+	}
+	err = addColumnIfMissing(db, "users", "suspended", "INTEGER NOT NULL DEFAULT 0")
+	if err != nil {
+		return fmt.Errorf("error updating users structure: %w", err)
+	}
+	err = addColumnIfMissing(db, "users", "email", "TEXT NOT NULL DEFAULT ''")
+	if err != nil {
+		return fmt.Errorf("error updating users structure: %w", err)
+	}
+	err = addColumnIfMissing(db, "users", "emailDigest", "TEXT NOT NULL DEFAULT 'off'")
+	if err != nil {
+		return fmt.Errorf("error updating users structure: %w", err)
+	}
+	err = addColumnIfMissing(db, "users", "lastDigestAt", "DATETIME")
+	if err != nil {
+		return fmt.Errorf("error updating users structure: %w", err)
+	}
+
+	mutesQuery := `CREATE TABLE IF NOT EXISTS muted_users (
+		userid INTEGER,
+		muteduserid INTEGER,
+		createdAt DATETIME,
+		PRIMARY KEY (userid, muteduserid),
+		FOREIGN KEY (userid) REFERENCES users(userid),
+		FOREIGN KEY (muteduserid) REFERENCES users(userid));`
+	_, err = db.Exec(mutesQuery)
+	if err != nil {
+		return fmt.Errorf("error creating mute structure: %w", err)
+	}
+
+	followRequestsQuery := `CREATE TABLE IF NOT EXISTS follow_requests (
+		userid INTEGER,
+		requesterid INTEGER,
+		createdAt DATETIME,
+		PRIMARY KEY (userid, requesterid),
+		FOREIGN KEY (userid) REFERENCES users(userid),
+		FOREIGN KEY (requesterid) REFERENCES users(userid));`
+	_, err = db.Exec(followRequestsQuery)
+	if err != nil {
+		return fmt.Errorf("error creating follow requests structure: %w", err)
+	}
+
+	// Temporary bans lift automatically: active_bans only lists the bans that did not expire yet.
+	// Expiration dates are stored in UTC, so that they can be compared with the SQLite clock.
+	activeBansQuery := `CREATE VIEW IF NOT EXISTS active_bans AS
+		SELECT userid, banneduserid, createdAt, reason, expiresAt FROM banned_users
+		WHERE expiresAt IS NULL OR expiresAt > datetime('now');`
+	_, err = db.Exec(activeBansQuery)
+	if err != nil {
+		return fmt.Errorf("error creating active bans view: %w", err)
+	}
+
+	commentRevisionsQuery := `CREATE TABLE IF NOT EXISTS comment_revisions (
+		revisionid INTEGER PRIMARY KEY AUTOINCREMENT,
+		commentid INTEGER,
+		commentText TEXT,
+		revisedAt DATETIME,
+		FOREIGN KEY(commentid) REFERENCES comments(commentid) ON DELETE CASCADE
+	);`
+
+	_, err = db.Exec(commentRevisionsQuery)
+	if err != nil {
+		return fmt.Errorf("error creating comment revisions structure: %w", err)
+	}
+
+	commentModerationQuery := `CREATE TABLE IF NOT EXISTS comment_moderation (
+		moderationid INTEGER PRIMARY KEY AUTOINCREMENT,
+		photoid INTEGER,
+		commentid INTEGER,
+		moderatorid INTEGER,
+		action TEXT,
+		actionDate DATETIME,
+		FOREIGN KEY(photoid) REFERENCES photos(photoid),
+		FOREIGN KEY(moderatorid) REFERENCES users(userid)
+	);`
+
+	_, err = db.Exec(commentModerationQuery)
+	if err != nil {
+		return fmt.Errorf("error creating comment moderation structure: %w", err)
+	}
+
+	reportsQuery := `CREATE TABLE IF NOT EXISTS reports (
+		reportid INTEGER PRIMARY KEY AUTOINCREMENT,
+		reporterid INTEGER,
+		targetType TEXT,
+		targetid INTEGER,
+		reason TEXT,
+		details TEXT,
+		status TEXT NOT NULL DEFAULT 'open',
+		createdAt DATETIME,
+		resolverid INTEGER,
+		action TEXT,
+		note TEXT,
+		resolvedAt DATETIME,
+		FOREIGN KEY(reporterid) REFERENCES users(userid),
+		FOREIGN KEY(resolverid) REFERENCES users(userid)
+	);`
+
+	_, err = db.Exec(reportsQuery)
+	if err != nil {
+		return fmt.Errorf("error creating reports structure: %w", err)
+	}
+
+	// The audit log is append-only: the triggers reject any change to the recorded events.
+	auditEventsQuery := `CREATE TABLE IF NOT EXISTS audit_events (
+		eventid INTEGER PRIMARY KEY AUTOINCREMENT,
+		actorid INTEGER,
+		action TEXT,
+		targetType TEXT,
+		targetid INTEGER,
+		details TEXT,
+		requestid TEXT,
+		createdAt DATETIME
+	);
+	CREATE INDEX IF NOT EXISTS audit_events_actor ON audit_events (actorid, createdAt);
+	CREATE INDEX IF NOT EXISTS audit_events_target ON audit_events (targetType, targetid, createdAt);
+	CREATE TRIGGER IF NOT EXISTS audit_events_no_update BEFORE UPDATE ON audit_events
+	BEGIN SELECT RAISE(ABORT, 'audit events cannot be changed'); END;
+	CREATE TRIGGER IF NOT EXISTS audit_events_no_delete BEFORE DELETE ON audit_events
+	BEGIN SELECT RAISE(ABORT, 'audit events cannot be deleted'); END;`
+
+	_, err = db.Exec(auditEventsQuery)
+	if err != nil {
+		return fmt.Errorf("error creating audit structure: %w", err)
+	}
+
+	webhooksQuery := `CREATE TABLE IF NOT EXISTS webhooks (
+		webhookid INTEGER PRIMARY KEY AUTOINCREMENT,
+		ownerid INTEGER,
+		url TEXT,
+		secret TEXT,
+		events TEXT,
+		createdAt DATETIME,
+		FOREIGN KEY(ownerid) REFERENCES users(userid)
+	);
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		deliveryid INTEGER PRIMARY KEY AUTOINCREMENT,
+		webhookid INTEGER,
+		event TEXT,
+		payload TEXT,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		nextAttemptAt DATETIME,
+		responseStatus INTEGER,
+		lastError TEXT,
+		createdAt DATETIME,
+		deliveredAt DATETIME,
+		FOREIGN KEY(webhookid) REFERENCES webhooks(webhookid) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries (status, nextAttemptAt);`
+
+	_, err = db.Exec(webhooksQuery)
+	if err != nil {
+		return fmt.Errorf("error creating webhooks structure: %w", err)
+	}
+
+	notificationsQuery := `CREATE TABLE IF NOT EXISTS notifications (
+		notificationid INTEGER PRIMARY KEY AUTOINCREMENT,
+		userid INTEGER,
+		actorid INTEGER,
+		type TEXT,
+		photoid INTEGER,
+		commentid INTEGER,
+		read INTEGER NOT NULL DEFAULT 0,
+		createdAt DATETIME,
+		FOREIGN KEY(userid) REFERENCES users(userid),
+		FOREIGN KEY(actorid) REFERENCES users(userid)
+	);
+	CREATE INDEX IF NOT EXISTS notifications_user ON notifications (userid, read, notificationid);`
+
+	_, err = db.Exec(notificationsQuery)
+	if err != nil {
+		return fmt.Errorf("error creating notifications structure: %w", err)
+	}
+	err = addColumnIfMissing(db, "notifications", "inApp", "INTEGER NOT NULL DEFAULT 1")
+	if err != nil {
+		return fmt.Errorf("error updating notifications structure: %w", err)
+	}
+	err = addColumnIfMissing(db, "notifications", "email", "INTEGER NOT NULL DEFAULT 1")
+	if err != nil {
+		return fmt.Errorf("error updating notifications structure: %w", err)
+	}
+
+	preferencesQuery := `CREATE TABLE IF NOT EXISTS notification_preferences (
+		userid INTEGER,
+		event TEXT,
+		inApp INTEGER NOT NULL DEFAULT 1,
+		push INTEGER NOT NULL DEFAULT 1,
+		email INTEGER NOT NULL DEFAULT 1,
+		PRIMARY KEY (userid, event),
+		FOREIGN KEY (userid) REFERENCES users(userid)
+	);`
+	_, err = db.Exec(preferencesQuery)
+	if err != nil {
+		return fmt.Errorf("error creating notification preferences structure: %w", err)
+	}
+	err = addColumnIfMissing(db, "users", "notificationsPausedUntil", "DATETIME")
+	if err != nil {
+		return fmt.Errorf("error updating users structure: %w", err)
+	}
+
+	timelinesQuery := `CREATE TABLE IF NOT EXISTS timelines (
+		userid INTEGER,
+		photoid INTEGER,
+		authorid INTEGER,
+		uploadDate DATETIME,
+		PRIMARY KEY (userid, photoid),
+		FOREIGN KEY (userid) REFERENCES users(userid),
+		FOREIGN KEY (photoid) REFERENCES photos(photoid),
+		FOREIGN KEY (authorid) REFERENCES users(userid)
+	);
+	CREATE INDEX IF NOT EXISTS timelines_user ON timelines (userid, uploadDate);
+	CREATE INDEX IF NOT EXISTS timelines_photo ON timelines (photoid);`
+	_, err = db.Exec(timelinesQuery)
+	if err != nil {
+		return fmt.Errorf("error creating timelines structure: %w", err)
+	}
+	err = addColumnIfMissing(db, "users", "streamSeenAt", "DATETIME")
+	if err != nil {
+		return fmt.Errorf("error updating users structure: %w", err)
+	}
+
+	collectionsQuery := `CREATE TABLE IF NOT EXISTS collections (
+		collectionid INTEGER PRIMARY KEY AUTOINCREMENT,
+		ownerid INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		createdAt DATETIME NOT NULL,
+		UNIQUE (ownerid, name),
+		FOREIGN KEY (ownerid) REFERENCES users(userid)
+	);
+	CREATE TABLE IF NOT EXISTS collection_items (
+		collectionid INTEGER,
+		photoid INTEGER,
+		addedAt DATETIME NOT NULL,
+		PRIMARY KEY (collectionid, photoid),
+		FOREIGN KEY (collectionid) REFERENCES collections(collectionid),
+		FOREIGN KEY (photoid) REFERENCES photos(photoid)
+	);
+	CREATE INDEX IF NOT EXISTS collection_items_photo ON collection_items (photoid);`
+	_, err = db.Exec(collectionsQuery)
+	if err != nil {
+		return fmt.Errorf("error creating collections structure: %w", err)
+	}
+
+	return nil
+}
+
+// addColumnIfMissing adds the column to the specified table, unless the table already has it.
+// It lets databases created by previous versions pick up new columns without being recreated.
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return fmt.Errorf("error reading %s columns: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return fmt.Errorf("error scanning %s column: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating over %s columns: %w", table, err)
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("error adding column %s to %s: %w", column, table, err)
+	}
 	return nil
 }
 
 func (db *appdbimpl) Ping() error {
-	return db.c.Ping()
+	return db.conn.Ping()
 }
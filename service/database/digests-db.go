@@ -0,0 +1,129 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Frequencies of the email digests.
+const (
+	DigestOff    = "off"    // No digests
+	DigestDaily  = "daily"  // A digest of the last day
+	DigestWeekly = "weekly" // A digest of the last week
+)
+
+// digestPeriods maps the frequencies of the email digests to the period they cover.
+var digestPeriods = map[string]time.Duration{
+	DigestDaily:  24 * time.Hour,
+	DigestWeekly: 7 * 24 * time.Hour,
+}
+
+// SetEmail sets the email address of the user. An empty address removes it.
+func (db *appdbimpl) SetEmail(userID int, email string) error {
+	_, err := db.c.Exec("UPDATE users SET email = ? WHERE userid = ?", email, userID)
+	if err != nil {
+		return fmt.Errorf("error updating email in database: %w", err)
+	}
+	return nil
+}
+
+// SetEmailDigest sets how often the user receives the email digests.
+func (db *appdbimpl) SetEmailDigest(userID int, frequency string) error {
+	if frequency != DigestOff && digestPeriods[frequency] == 0 {
+		return fmt.Errorf("invalid digest frequency %q", frequency)
+	}
+
+	_, err := db.c.Exec("UPDATE users SET emailDigest = ? WHERE userid = ?", frequency, userID)
+	if err != nil {
+		return fmt.Errorf("error updating email digest in database: %w", err)
+	}
+	return nil
+}
+
+// GetDueDigests returns the digests to send at the specified time: one for every user with an email address whose last
+// digest is older than their frequency. A digest covers the unread notifications received since the last one, or in
+// the last period for the first one; notifications excluded from the digests by the user's preferences, or from
+// banned, muted or suspended users, are left out. Digests with no notifications are returned too, so that the period
+// is marked as covered.
+func (db *appdbimpl) GetDueDigests(now time.Time) ([]Digest, error) {
+	var digests []Digest
+
+	rows, err := db.c.Query(`SELECT userid, username, email, emailDigest, lastDigestAt FROM users
+		WHERE email != '' AND emailDigest != ? AND suspended = 0 ORDER BY userid`, DigestOff)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching digest recipients: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	for rows.Next() {
+		var d Digest
+		var lastDigestAt sql.NullTime
+		if err := rows.Scan(&d.UserID, &d.Username, &d.Email, &d.Frequency, &lastDigestAt); err != nil {
+			return nil, fmt.Errorf("error scanning digest recipient row: %w", err)
+		}
+
+		period := digestPeriods[d.Frequency]
+		if period == 0 || (lastDigestAt.Valid && now.Sub(lastDigestAt.Time) < period) {
+			continue // Not due yet
+		}
+		d.Since = now.Add(-period)
+		if lastDigestAt.Valid {
+			d.Since = lastDigestAt.Time
+		}
+		digests = append(digests, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over digest recipient rows: %w", err)
+	}
+
+	// Count the unread notifications of each recipient.
+	for i := range digests {
+		digests[i].Counts, err = db.countUnreadNotifications(digests[i].UserID, digests[i].Since, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return digests, nil
+}
+
+// countUnreadNotifications returns the number of unread notifications of the user received in the specified period,
+// by type.
+func (db *appdbimpl) countUnreadNotifications(userID int, since, until time.Time) (map[string]int, error) {
+	counts := make(map[string]int)
+
+	rows, err := db.c.Query(`SELECT type, COUNT(*) FROM notifications
+		WHERE userid = ? AND read = 0 AND email = 1 AND createdAt > ? AND createdAt <= ?
+			AND `+notBannedCondition("actorid")+` AND `+notMutedCondition("actorid")+` AND `+notSuspendedCondition("actorid")+`
+		GROUP BY type`, userID, since, until, userID, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error counting notifications: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	for rows.Next() {
+		var notificationType string
+		var count int
+		if err := rows.Scan(&notificationType, &count); err != nil {
+			return nil, fmt.Errorf("error scanning notification count row: %w", err)
+		}
+		counts[notificationType] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over notification count rows: %w", err)
+	}
+
+	return counts, nil
+}
+
+// SetDigestSent records that the digest of the user covering the notifications until the specified time has been sent.
+func (db *appdbimpl) SetDigestSent(userID int, until time.Time) error {
+	_, err := db.c.Exec("UPDATE users SET lastDigestAt = ? WHERE userid = ?", until, userID)
+	if err != nil {
+		return fmt.Errorf("error updating last digest in database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GetTrendingPhotos returns the photos with the most likes and comments per hour in the specified window, best first.
+// The activity is normalized by the square root of the number of followers of the author, so that smaller accounts can
+// trend too. Only photos of public, non-suspended accounts are included; the photos are not filtered for any viewer.
+func (db *appdbimpl) GetTrendingPhotos(window time.Duration, limit int) ([]TrendingPhoto, error) {
+	now := time.Now()
+	since := now.Add(-window)
+
+	rows, err := db.c.Query(`SELECT p.photoid, p.userid, p.uploadDate,
+			(SELECT COUNT(*) FROM likes l WHERE l.photoid = p.photoid AND l.createdAt >= ?) AS likes,
+			(SELECT COUNT(*) FROM comments c WHERE c.photoid = p.photoid AND c.uploadDate >= ? AND c.hidden = 0) AS comments,
+			(SELECT COUNT(*) FROM followers f WHERE f.userid = p.userid AND `+notSuspendedCondition("f.followerid")+`) AS followers
+		FROM photos p JOIN users u ON p.userid = u.userid
+		WHERE u.private = 0 AND u.suspended = 0
+			AND p.photoid IN (SELECT photoid FROM likes WHERE createdAt >= ? UNION SELECT photoid FROM comments WHERE uploadDate >= ?)`,
+		since, since, since, since)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching trending candidates: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	// Iterate over the candidates to score them.
+	var trending []TrendingPhoto
+	for rows.Next() {
+		var t TrendingPhoto
+		var uploadDate time.Time
+		var likes, comments, followers int
+		if err := rows.Scan(&t.PhotoID, &t.UserID, &uploadDate, &likes, &comments, &followers); err != nil {
+			return nil, fmt.Errorf("error scanning trending candidate row: %w", err)
+		}
+
+		// The activity is spread over the part of the window the photo existed in, and at least an hour.
+		active := now.Sub(uploadDate)
+		if active > window {
+			active = window
+		}
+		hours := math.Max(active.Hours(), 1)
+
+		t.Score = float64(likes+comments) / hours / math.Sqrt(float64(1+followers))
+		if t.Score > 0 {
+			trending = append(trending, t)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over trending candidate rows: %w", err)
+	}
+
+	// Sort the photos by score, the most recent first on ties.
+	sort.Slice(trending, func(i, j int) bool {
+		if trending[i].Score != trending[j].Score {
+			return trending[i].Score > trending[j].Score
+		}
+		return trending[i].PhotoID > trending[j].PhotoID
+	})
+	if len(trending) > limit {
+		trending = trending[:limit]
+	}
+
+	return trending, nil
+}
+
+// GetExplorePhotos returns a page of the trending photos the viewer can see, in the order of the ranking. Photos of
+// users who banned the viewer, or were banned by them, of suspended users and of private accounts the viewer does not
+// follow are left out, as well as the photos deleted since the ranking was computed.
+func (db *appdbimpl) GetExplorePhotos(viewerID int, trending []TrendingPhoto, limit, offset int) ([]CompletePhoto, error) {
+	photos := make([]CompletePhoto, 0, limit)
+	if len(trending) == 0 {
+		return photos, nil
+	}
+
+	// Pass the ranking as a table of photo IDs and positions.
+	values := make([]string, len(trending))
+	args := make([]interface{}, 0, 2*len(trending)+6)
+	for i, t := range trending {
+		values[i] = "(?, ?)"
+		args = append(args, t.PhotoID, i)
+	}
+	args = append(args, viewerID, viewerID, viewerID, viewerID, limit, offset)
+
+	rows, err := db.c.Query(`WITH ranking (photoid, position) AS (VALUES `+strings.Join(values, ", ")+`)
+		SELECT p.photoid, p.userid, p.username, p.imageData, p.uploadDate, p.likesCount, p.commentsCount, p.commentsEnabled
+		FROM ranking r JOIN photos p ON r.photoid = p.photoid
+		WHERE `+notBannedCondition("p.userid")+` AND `+notSuspendedCondition("p.userid")+` AND `+visibleAccountCondition("p.userid")+`
+		ORDER BY r.position LIMIT ? OFFSET ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching explore photos: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	for rows.Next() {
+		var photo CompletePhoto
+		if err := rows.Scan(&photo.PhotoID, &photo.UserID, &photo.Username, &photo.ImageData, &photo.UploadDate, &photo.LikesCount, &photo.CommentsCount, &photo.CommentsEnabled); err != nil {
+			return nil, fmt.Errorf("error scanning explore photo row: %w", err)
+		}
+		photos = append(photos, photo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over explore photo rows: %w", err)
+	}
+
+	// Retrieve the likes and comments of each photo, once the rows are closed.
+	for i := range photos {
+		if err := db.getPhotoDetails(viewerID, &photos[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return photos, nil
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// rankedPhoto is a candidate of the ranked stream, with the signals used to score it.
+type rankedPhoto struct {
+	photo        CompletePhoto
+	followed     bool // The viewer follows the author
+	interactions int  // Likes and comments of the viewer on the author's photos
+	score        float64
+}
+
+// GetRankedStream returns a page of the photos the specified user may like the most, best first. The candidates are
+// the photos uploaded in the candidate window by the users followed by the viewer and, if weights.Popular is not zero,
+// the liked photos of public accounts the viewer does not follow. Each candidate is scored by its freshness, its likes
+// and comments per hour since the upload, and how much the viewer interacted with the author. Photos of users who are
+// banned, muted or suspended are left out, as in GetMyStream.
+func (db *appdbimpl) GetRankedStream(userID int, weights FeedWeights, limit, offset int) ([]CompletePhoto, error) {
+	now := time.Now()
+
+	rows, err := db.c.Query(`WITH followed AS (SELECT followingid FROM following WHERE userid = ?)
+		SELECT p.photoid, p.userid, p.username, p.uploadDate, p.likesCount, p.commentsCount, p.commentsEnabled,
+			p.userid IN followed AS isFollowed,
+			(SELECT COUNT(*) FROM likes l JOIN photos ap ON l.photoid = ap.photoid WHERE l.userid = ? AND ap.userid = p.userid)
+				+ (SELECT COUNT(*) FROM comments c JOIN photos ap ON c.photoid = ap.photoid WHERE c.userid = ? AND ap.userid = p.userid) AS interactions
+		FROM photos p JOIN users u ON p.userid = u.userid
+		WHERE p.uploadDate >= ? AND p.userid <> ?
+			AND (p.userid IN followed OR (? AND u.private = 0 AND p.likesCount > 0))
+			AND `+notBannedCondition("p.userid")+` AND `+notMutedCondition("p.userid")+` AND `+notSuspendedCondition("p.userid")+`
+		ORDER BY p.uploadDate DESC LIMIT ?`,
+		userID, userID, userID, now.Add(-weights.CandidateWindow), userID, weights.Popular > 0, userID, userID, userID,
+		weights.MaxCandidates)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching stream candidates: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	// Iterate over the candidates to score them.
+	var candidates []rankedPhoto
+	for rows.Next() {
+		var c rankedPhoto
+		if err := rows.Scan(&c.photo.PhotoID, &c.photo.UserID, &c.photo.Username, &c.photo.UploadDate, &c.photo.LikesCount,
+			&c.photo.CommentsCount, &c.photo.CommentsEnabled, &c.followed, &c.interactions); err != nil {
+			return nil, fmt.Errorf("error scanning stream candidate row: %w", err)
+		}
+		c.score = scorePhoto(c, weights, now)
+		candidates = append(candidates, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over stream candidate rows: %w", err)
+	}
+
+	// Sort the candidates by score, the most recent first on ties.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	if offset >= len(candidates) {
+		return nil, nil
+	}
+	candidates = candidates[offset:]
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	// Load the image, likes and comments of the photos in the page only.
+	stream := make([]CompletePhoto, 0, len(candidates))
+	for _, c := range candidates {
+		photo := c.photo
+		if err := db.c.QueryRow("SELECT imageData FROM photos WHERE photoid = ?", photo.PhotoID).Scan(&photo.ImageData); err != nil {
+			return nil, fmt.Errorf("error fetching image for photoID %d: %w", photo.PhotoID, err)
+		}
+		if err := db.getPhotoDetails(userID, &photo); err != nil {
+			return nil, err
+		}
+		stream = append(stream, photo)
+	}
+
+	return stream, nil
+}
+
+// scorePhoto computes the score of a candidate of the ranked stream at the specified time.
+func scorePhoto(c rankedPhoto, weights FeedWeights, now time.Time) float64 {
+	age := now.Sub(c.photo.UploadDate)
+
+	// Velocities are computed over at least an hour, so that new photos are not overrated.
+	hours := math.Max(age.Hours(), 1)
+
+	score := weights.Recency*math.Exp2(-float64(age)/float64(weights.RecencyHalfLife)) +
+		weights.Likes*float64(c.photo.LikesCount)/hours +
+		weights.Comments*float64(c.photo.CommentsCount)/hours +
+		weights.Affinity*math.Log1p(float64(c.interactions))
+
+	if !c.followed {
+		score *= weights.Popular
+	}
+	return score
+}
@@ -0,0 +1,212 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Types of notifications.
+const (
+	NotificationFollow        = "follow"
+	NotificationFollowRequest = "follow-request"
+	NotificationLike          = "like"
+	NotificationComment       = "comment"
+	NotificationMention       = "mention"
+)
+
+// mentionPattern matches the mentions of a username in a comment, such as "@alice".
+var mentionPattern = regexp.MustCompile(`@([a-zA-Z0-9]{3,16})\b`)
+
+// notify records a notification for the recipient about an action of the actor. Nothing is recorded for the actor's
+// own actions, or if there is a ban between the two users, or if the recipient muted the actor, or if the recipient's
+// preferences exclude both the in-app list and the email digests. Zero photo and comment IDs are stored as NULL.
+func (db *appdbimpl) notify(recipientID, actorID int, notificationType string, photoID, commentID int) error {
+	if recipientID == actorID {
+		return nil
+	}
+
+	if err := db.checkBan(actorID, recipientID); errors.Is(err, ErrBannedByUser) || errors.Is(err, ErrUserBanned) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if muted, err := db.HasMuted(recipientID, actorID); err != nil {
+		return err
+	} else if muted {
+		return nil
+	}
+
+	channels, err := db.GetNotificationChannels(recipientID, notificationType)
+	if err != nil {
+		return err
+	}
+	if !channels.InApp && !channels.Email {
+		return nil
+	}
+
+	var photo, comment sql.NullInt64
+	if photoID != 0 {
+		photo = sql.NullInt64{Int64: int64(photoID), Valid: true}
+	}
+	if commentID != 0 {
+		comment = sql.NullInt64{Int64: int64(commentID), Valid: true}
+	}
+
+	_, err = db.c.Exec(`INSERT INTO notifications (userid, actorid, type, photoid, commentid, inApp, email, createdAt)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, recipientID, actorID, notificationType, photo, comment, channels.InApp, channels.Email, time.Now())
+	if err != nil {
+		return fmt.Errorf("error inserting notification: %w", err)
+	}
+	return nil
+}
+
+// notifyMentions notifies the users mentioned in a comment. The owner of the photo, who is already notified of the
+// comment, and users who cannot see the photo are skipped.
+func (db *appdbimpl) notifyMentions(actorID, photoOwnerID, photoID, commentID int, text string) error {
+	notified := map[int]bool{actorID: true, photoOwnerID: true}
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		var mentionedID int
+		err := db.c.QueryRow("SELECT userid FROM users WHERE LOWER(username) = LOWER(?)", match[1]).Scan(&mentionedID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue // Not a user
+		} else if err != nil {
+			return fmt.Errorf("error checking mentioned user: %w", err)
+		}
+		if notified[mentionedID] {
+			continue
+		}
+		notified[mentionedID] = true
+
+		if err := db.checkBan(mentionedID, photoOwnerID); err != nil {
+			continue
+		}
+		if err := db.checkPrivacy(mentionedID, photoOwnerID); err != nil {
+			continue
+		}
+
+		if err := db.notify(mentionedID, actorID, NotificationMention, photoID, commentID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// groupCondition returns the SQL expression identifying the group of a notification: likes and comments on the same
+// photo are aggregated, while every other notification is shown on its own.
+func groupCondition() string {
+	return fmt.Sprintf("CASE WHEN type IN ('%s', '%s') THEN type || '-' || photoid ELSE 'notification-' || notificationid END",
+		NotificationLike, NotificationComment)
+}
+
+// GetNotifications returns a page of the specified user's notifications, most recent first. Read and unread
+// notifications are aggregated separately. Notifications from users who are banned, muted or suspended are left out.
+func (db *appdbimpl) GetNotifications(userID, limit, offset int) ([]Notification, error) {
+	var notifications []Notification
+
+	// SQLite takes the values of the non-aggregated columns from the row with the highest notification ID.
+	rows, err := db.c.Query(`SELECT g.notificationid, g.type, g.actorid, u.username, g.actors, g.photoid, g.commentid, g.read, g.createdAt
+		FROM (SELECT MAX(notificationid) AS notificationid, type, actorid, COUNT(DISTINCT actorid) AS actors, photoid, commentid, read, createdAt
+			FROM notifications
+			WHERE userid = ? AND inApp = 1 AND `+notBannedCondition("actorid")+` AND `+notMutedCondition("actorid")+` AND `+notSuspendedCondition("actorid")+`
+			GROUP BY read, `+groupCondition()+`) g
+		JOIN users u ON g.actorid = u.userid
+		ORDER BY g.notificationid DESC LIMIT ? OFFSET ?`, userID, userID, userID, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching notifications: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	// Iterate over the rows to extract each notification's data.
+	for rows.Next() {
+		var n Notification
+		var photoID, commentID sql.NullInt64
+		if err := rows.Scan(&n.NotificationID, &n.Type, &n.ActorID, &n.ActorUsername, &n.ActorsCount, &photoID, &commentID,
+			&n.Read, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning notification row: %w", err)
+		}
+		n.PhotoID = int(photoID.Int64)
+		n.CommentID = int(commentID.Int64)
+		n.Message = notificationMessage(n)
+		notifications = append(notifications, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over notification rows: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// notificationMessage describes a notification, such as "alice and 4 others liked your photo".
+func notificationMessage(n Notification) string {
+	actors := n.ActorUsername
+	switch {
+	case n.ActorsCount == 2:
+		actors += " and 1 other"
+	case n.ActorsCount > 2:
+		actors += fmt.Sprintf(" and %d others", n.ActorsCount-1)
+	}
+
+	switch n.Type {
+	case NotificationFollow:
+		return actors + " started following you"
+	case NotificationFollowRequest:
+		return actors + " asked to follow you"
+	case NotificationLike:
+		return actors + " liked your photo"
+	case NotificationComment:
+		return actors + " commented on your photo"
+	case NotificationMention:
+		return actors + " mentioned you in a comment"
+	default:
+		return actors
+	}
+}
+
+// GetUnreadNotificationsCount returns the number of unread notifications of the specified user, with the same
+// filters as GetNotifications.
+func (db *appdbimpl) GetUnreadNotificationsCount(userID int) (int, error) {
+	var count int
+	err := db.c.QueryRow(`SELECT COUNT(*) FROM notifications
+		WHERE userid = ? AND read = 0 AND inApp = 1 AND `+notBannedCondition("actorid")+` AND `+notMutedCondition("actorid")+` AND `+notSuspendedCondition("actorid"),
+		userID, userID, userID, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// SetNotificationRead marks a notification of the specified user as read or unread, together with the other
+// notifications aggregated with it.
+func (db *appdbimpl) SetNotificationRead(userID, notificationID int, read bool) error {
+	// Check if the notification exists and belongs to the user.
+	var group string
+	var wasRead bool
+	err := db.c.QueryRow("SELECT "+groupCondition()+", read FROM notifications WHERE notificationid = ? AND userid = ? AND inApp = 1",
+		notificationID, userID).Scan(&group, &wasRead)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sql.ErrNoRows // Notification not found
+	} else if err != nil {
+		return fmt.Errorf("error checking existing notification: %w", err)
+	}
+
+	_, err = db.c.Exec("UPDATE notifications SET read = ? WHERE userid = ? AND read = ? AND inApp = 1 AND "+groupCondition()+" = ?",
+		read, userID, wasRead, group)
+	if err != nil {
+		return fmt.Errorf("error updating notification: %w", err)
+	}
+	return nil
+}
+
+// MarkAllNotificationsRead marks every notification of the specified user as read.
+func (db *appdbimpl) MarkAllNotificationsRead(userID int) error {
+	_, err := db.c.Exec("UPDATE notifications SET read = 1 WHERE userid = ? AND read = 0 AND inApp = 1", userID)
+	if err != nil {
+		return fmt.Errorf("error updating notifications: %w", err)
+	}
+	return nil
+}
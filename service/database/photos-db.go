@@ -4,25 +4,40 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 )
 
 // CreatePhoto uploads a new photo to the database.
 func (db *appdbimpl) CreatePhoto(p Photo) (Photo, error) {
 
-	// Insert the new photo into the database.
-	result, err := db.c.Exec("INSERT INTO photos (userid, username, imageData, uploadDate, likesCount, commentsCount) VALUES (?, ?, ?, ?, ?, ?)", p.UserID, p.Username, p.ImageData, p.UploadDate, p.LikesCount, p.CommentsCount)
-	if err != nil {
-		return p, fmt.Errorf("error creating photo in database: %w", err)
-	}
-
-	// Get the ID of the newly created photo.
-	id, err := result.LastInsertId()
-	if err != nil {
-		return p, err
-	}
-
-	p.PhotoID = int(id)
-	return p, nil
+	// Store the photo, its timeline entries and its webhook event together.
+	err := db.withTx(func(tx *appdbimpl) error {
+		// Insert the new photo into the database.
+		result, err := tx.c.Exec("INSERT INTO photos (userid, username, imageData, uploadDate, likesCount, commentsCount, commentsEnabled) VALUES (?, ?, ?, ?, ?, ?, ?)", p.UserID, p.Username, p.ImageData, p.UploadDate, p.LikesCount, p.CommentsCount, p.CommentsEnabled)
+		if err != nil {
+			return fmt.Errorf("error creating photo in database: %w", err)
+		}
+
+		// Get the ID of the newly created photo.
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		p.PhotoID = int(id)
+
+		if err := tx.fanOutPhoto(p); err != nil {
+			return err
+		}
+
+		return tx.enqueueWebhookEvent(WebhookEventPhotoCreated, p.UserID, struct {
+			PhotoID    int       `json:"photoID"`
+			UserID     int       `json:"userID"`
+			Username   string    `json:"username"`
+			UploadDate time.Time `json:"uploadDate"`
+		}{p.PhotoID, p.UserID, p.Username, p.UploadDate})
+	})
+	return p, err
 }
 
 // LikePhoto adds a like to a photo in the database.
@@ -51,35 +66,48 @@ func (db *appdbimpl) LikePhoto(userID int, photoID int, l Like) error {
 		return err
 	}
 
-	// Check if the user who posted the photo has banned the current user.
-	var isBanned int
-	err = db.c.QueryRow("SELECT 1 FROM banned_users WHERE userid = ? AND banneduserid = ?", userID, photoAuthorID).Scan(&isBanned)
-	if err == nil {
-		return fmt.Errorf("cannot like a photo published by a user who has banned you")
+	// The photos of suspended users are reported as not found.
+	if err := db.checkSuspended(photoAuthorID); errors.Is(err, ErrUserSuspended) {
+		return sql.ErrNoRows
+	} else if err != nil {
+		return err
 	}
 
-	// Increment the number of likes on the photo.
-	_, err = db.c.Exec("UPDATE photos SET likesCount = likesCount + 1 WHERE photoid = ?", photoID)
-	if err != nil {
-		return fmt.Errorf("error updating likesCount in database: %w", err)
+	// Check if there is a ban between the current user and the user who posted the photo.
+	if err := db.checkBan(userID, photoAuthorID); err != nil {
+		return fmt.Errorf("cannot like this photo: %w", err)
 	}
 
-	// Insert the like into the likes table.
-	result, err := db.c.Exec("INSERT INTO likes (userID, photoID) VALUES (?, ?)", userID, photoID)
-	if err != nil {
-		return fmt.Errorf("error inserting like into database: %w", err)
+	// Only approved followers can interact with the photos of private accounts.
+	if err := db.checkPrivacy(userID, photoAuthorID); err != nil {
+		return fmt.Errorf("cannot like this photo: %w", err)
 	}
 
-	// Get the ID of the newly created like.
-	likeID, err := result.LastInsertId()
-	if err != nil {
-		return err
-	}
-	l.UserID = userID
-	l.PhotoID = photoID
-	l.LikeID = int(likeID)
-
-	return nil
+	// Store the like and notify the owner of the photo together.
+	return db.withTx(func(tx *appdbimpl) error {
+		// Increment the number of likes on the photo.
+		_, err := tx.c.Exec("UPDATE photos SET likesCount = likesCount + 1 WHERE photoid = ?", photoID)
+		if err != nil {
+			return fmt.Errorf("error updating likesCount in database: %w", err)
+		}
+
+		// Insert the like into the likes table.
+		result, err := tx.c.Exec("INSERT INTO likes (userID, photoID, createdAt) VALUES (?, ?, ?)", userID, photoID, time.Now())
+		if err != nil {
+			return fmt.Errorf("error inserting like into database: %w", err)
+		}
+
+		// Get the ID of the newly created like.
+		likeID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		l.UserID = userID
+		l.PhotoID = photoID
+		l.LikeID = int(likeID)
+
+		return tx.notify(photoAuthorID, userID, NotificationLike, photoID, 0)
+	})
 }
 
 // UnlikePhoto removes a specific like from the specified photo in the database.
@@ -114,98 +142,541 @@ func (db *appdbimpl) UnlikePhoto(userID, photoID, likeID int) error {
 		return fmt.Errorf("error removing like from database: %w", err)
 	}
 
+	// Withdraw the notification of the like.
+	_, err = db.c.Exec("DELETE FROM notifications WHERE actorid = ? AND photoid = ? AND type = ?", userID, photoID, NotificationLike)
+	if err != nil {
+		return fmt.Errorf("error removing like notification: %w", err)
+	}
+
 	return nil
 }
 
 // CommentPhoto adds a comment to a photo in the database.
 func (db *appdbimpl) CommentPhoto(userID, photoID int, authorUsername string, c Comment) (Comment, error) {
-	// Check if the photo exists.
-	var existingPhoto int
-	err := db.c.QueryRow("SELECT 1 FROM photos WHERE photoid = ?", photoID).Scan(&existingPhoto)
+	// Check if the photo exists and accepts comments.
+	var commentsEnabled bool
+	err := db.c.QueryRow("SELECT commentsEnabled FROM photos WHERE photoid = ?", photoID).Scan(&commentsEnabled)
 	if errors.Is(err, sql.ErrNoRows) {
 		return c, sql.ErrNoRows // Photo not found
 	} else if err != nil {
 		return c, fmt.Errorf("error checking existing photo: %w", err)
 	}
 
+	if !commentsEnabled {
+		return c, errors.New("comments are turned off for this photo")
+	}
+
 	// Get the ID of the user who posted the photo.
 	photoAuthorID, err := db.GetPhotoUserID(photoID)
 	if err != nil {
 		return c, err
 	}
 
-	// Check if the user who posted the photo has banned the current user.
-	var isBanned int
-	err = db.c.QueryRow("SELECT 1 FROM banned_users WHERE userid = ? AND banneduserid = ?", userID, photoAuthorID).Scan(&isBanned)
-	if err == nil {
-		return c, fmt.Errorf("cannot comment a photo published by a user who has banned you")
-	}
-
-	// Add the comment to the comments table.
-	result, err := db.c.Exec("INSERT INTO comments (userid, username, photoid, commentText, uploadDate) VALUES (?, ?, ?, ?, ?)", userID, authorUsername, photoID, c.CommentText, c.UploadDate)
-	if err != nil {
-		return c, fmt.Errorf("error inserting comment into database: %w", err)
-	}
-
-	// Get the ID of the newly created comment.
-	commentID, err := result.LastInsertId()
-	if err != nil {
+	// The photos of suspended users are reported as not found.
+	if err := db.checkSuspended(photoAuthorID); errors.Is(err, ErrUserSuspended) {
+		return c, sql.ErrNoRows
+	} else if err != nil {
 		return c, err
 	}
 
-	// Increment the number of comments on the photo.
-	_, err = db.c.Exec("UPDATE photos SET commentsCount = commentsCount + 1 WHERE photoid = ?", photoID)
-	if err != nil {
-		return c, fmt.Errorf("error updating commentsCount in database: %w", err)
+	// Check if there is a ban between the current user and the user who posted the photo.
+	if err := db.checkBan(userID, photoAuthorID); err != nil {
+		return c, fmt.Errorf("cannot comment this photo: %w", err)
 	}
 
-	c.AuthorID = userID
-	c.AuthorUsername = authorUsername
-	c.CommentID = int(commentID)
-	c.PhotoID = photoID
+	// Only approved followers can interact with the photos of private accounts.
+	if err := db.checkPrivacy(userID, photoAuthorID); err != nil {
+		return c, fmt.Errorf("cannot comment this photo: %w", err)
+	}
 
-	return c, nil
+	// Store the comment and notify the owner of the photo and the mentioned users together.
+	err = db.withTx(func(tx *appdbimpl) error {
+		// Add the comment to the comments table.
+		result, err := tx.c.Exec("INSERT INTO comments (userid, username, photoid, commentText, uploadDate) VALUES (?, ?, ?, ?, ?)", userID, authorUsername, photoID, c.CommentText, c.UploadDate)
+		if err != nil {
+			return fmt.Errorf("error inserting comment into database: %w", err)
+		}
+
+		// Get the ID of the newly created comment.
+		commentID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		// Increment the number of comments on the photo.
+		_, err = tx.c.Exec("UPDATE photos SET commentsCount = commentsCount + 1 WHERE photoid = ?", photoID)
+		if err != nil {
+			return fmt.Errorf("error updating commentsCount in database: %w", err)
+		}
+
+		c.AuthorID = userID
+		c.AuthorUsername = authorUsername
+		c.CommentID = int(commentID)
+		c.PhotoID = photoID
+
+		// Notify the owner of the photo and the mentioned users.
+		if err := tx.notify(photoAuthorID, userID, NotificationComment, photoID, c.CommentID); err != nil {
+			return err
+		}
+		if err := tx.notifyMentions(userID, photoAuthorID, photoID, c.CommentID, c.CommentText); err != nil {
+			return err
+		}
+
+		return nil
+	})
+	return c, err
 }
 
 // UncommentPhoto removes a specific comment from the specified photo in the database.
+// Comments can be removed by their author or by the owner of the photo.
 func (db *appdbimpl) UncommentPhoto(userID, photoID, commentID int) error {
 	// Check if the photo exists.
-	var existingPhoto int
-	err := db.c.QueryRow("SELECT 1 FROM photos WHERE photoid = ?", photoID).Scan(&existingPhoto)
+	photoAuthorID, err := db.GetPhotoUserID(photoID)
 	if errors.Is(err, sql.ErrNoRows) {
 		return sql.ErrNoRows // Photo not found
 	} else if err != nil {
 		return fmt.Errorf("error checking existing photo: %w", err)
 	}
 
-	// Check if the comment exists and if the user who is trying to delete it is the author.
+	// Check if the comment exists and if the user who is trying to delete it is the author or the photo owner.
 	var commentAuthorID int
-	err = db.c.QueryRow("SELECT userID FROM comments WHERE commentid = ? AND photoid = ?", commentID, photoID).Scan(&commentAuthorID)
+	var hidden bool
+	err = db.c.QueryRow("SELECT userID, hidden FROM comments WHERE commentid = ? AND photoid = ?", commentID, photoID).Scan(&commentAuthorID, &hidden)
 	if errors.Is(err, sql.ErrNoRows) {
 		return sql.ErrNoRows // Comment not found
 	} else if err != nil {
 		return fmt.Errorf("error checking existing comment: %w", err)
 	}
 
-	if commentAuthorID != userID {
-		return errors.New("cannot delete comments not published by you")
+	if commentAuthorID != userID && photoAuthorID != userID {
+		return errors.New("cannot delete comments not published by you or on photos not published by you")
 	}
 
-	// Decrement the number of comments on the photo.
-	_, err = db.c.Exec("UPDATE photos SET commentsCount = commentsCount - 1 WHERE photoid = ?", photoID)
-	if err != nil {
-		return fmt.Errorf("error updating commentsCount in database: %w", err)
+	// Remove the comment and record the removal together.
+	return db.withTx(func(tx *appdbimpl) error {
+		if err := tx.removeComment(photoID, commentID, hidden); err != nil {
+			return err
+		}
+
+		// Record the removal if the photo owner deleted someone else's comment.
+		details := ""
+		if commentAuthorID != userID {
+			if err := tx.logCommentModeration(userID, photoID, commentID, "delete"); err != nil {
+				return err
+			}
+			details = "removed by the photo owner"
+		}
+
+		return tx.logAuditEvent(userID, AuditCommentDelete, AuditTargetComment, commentID, details)
+	})
+}
+
+// removeComment deletes a comment and its edit history, without any permission check.
+func (db *appdbimpl) removeComment(photoID, commentID int, hidden bool) error {
+	// Decrement the number of comments on the photo. Hidden comments are already excluded from the count.
+	if !hidden {
+		_, err := db.c.Exec("UPDATE photos SET commentsCount = commentsCount - 1 WHERE photoid = ?", photoID)
+		if err != nil {
+			return fmt.Errorf("error updating commentsCount in database: %w", err)
+		}
 	}
 
 	// Remove the comment from the comments table.
-	_, err = db.c.Exec("DELETE FROM comments WHERE commentid = ? AND userid = ? AND photoid = ?", commentID, userID, photoID)
+	_, err := db.c.Exec("DELETE FROM comments WHERE commentid = ? AND photoid = ?", commentID, photoID)
 	if err != nil {
 		return fmt.Errorf("error removing comment from database: %w", err)
 	}
 
+	// Remove the edit history of the comment.
+	_, err = db.c.Exec("DELETE FROM comment_revisions WHERE commentid = ?", commentID)
+	if err != nil {
+		return fmt.Errorf("error removing comment revisions from database: %w", err)
+	}
+
+	// Remove the notifications of the comment and its mentions.
+	_, err = db.c.Exec("DELETE FROM notifications WHERE commentid = ?", commentID)
+	if err != nil {
+		return fmt.Errorf("error removing comment notifications from database: %w", err)
+	}
+
 	return nil
 }
 
+// EditComment replaces the text of a comment, keeping the previous text in the comment's edit history.
+func (db *appdbimpl) EditComment(userID, photoID, commentID int, c Comment) (Comment, error) {
+	// Check if the photo exists.
+	var existingPhoto int
+	err := db.c.QueryRow("SELECT 1 FROM photos WHERE photoid = ?", photoID).Scan(&existingPhoto)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, sql.ErrNoRows // Photo not found
+	} else if err != nil {
+		return c, fmt.Errorf("error checking existing photo: %w", err)
+	}
+
+	// Check if the comment exists and if the user who is trying to edit it is the author.
+	var comment Comment
+	err = db.c.QueryRow("SELECT commentid, userid, username, photoid, commentText, uploadDate FROM comments WHERE commentid = ? AND photoid = ?", commentID, photoID).
+		Scan(&comment.CommentID, &comment.AuthorID, &comment.AuthorUsername, &comment.PhotoID, &comment.CommentText, &comment.UploadDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, sql.ErrNoRows // Comment not found
+	} else if err != nil {
+		return c, fmt.Errorf("error checking existing comment: %w", err)
+	}
+
+	if comment.AuthorID != userID {
+		return c, errors.New("cannot edit comments not published by you")
+	}
+
+	// Check if there is a ban between the author and the user who posted the photo.
+	photoAuthorID, err := db.GetPhotoUserID(photoID)
+	if err != nil {
+		return c, err
+	}
+	if err := db.checkBan(userID, photoAuthorID); err != nil {
+		return c, fmt.Errorf("cannot edit this comment: %w", err)
+	}
+
+	// Save the current text as a revision and replace it together.
+	err = db.withTx(func(tx *appdbimpl) error {
+		_, err := tx.c.Exec("INSERT INTO comment_revisions (commentid, commentText, revisedAt) VALUES (?, ?, ?)", commentID, comment.CommentText, c.EditedAt)
+		if err != nil {
+			return fmt.Errorf("error inserting comment revision into database: %w", err)
+		}
+
+		// Update the comment text and its edit date.
+		_, err = tx.c.Exec("UPDATE comments SET commentText = ?, editedAt = ? WHERE commentid = ?", c.CommentText, c.EditedAt, commentID)
+		if err != nil {
+			return fmt.Errorf("error updating comment in database: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return c, err
+	}
+
+	comment.CommentText = c.CommentText
+	comment.EditedAt = c.EditedAt
+
+	return comment, nil
+}
+
+// GetCommentRevisions returns the previous versions of a comment, oldest first.
+// Only the owner of the photo can read the edit history of its comments.
+func (db *appdbimpl) GetCommentRevisions(userID, photoID, commentID int) ([]CommentRevision, error) {
+	// Check if the photo exists and belongs to the user.
+	photoAuthorID, err := db.GetPhotoUserID(photoID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, sql.ErrNoRows // Photo not found
+	} else if err != nil {
+		return nil, err
+	}
+
+	if photoAuthorID != userID {
+		return nil, errors.New("cannot view the edit history of comments on photos not published by you")
+	}
+
+	// Check if the comment exists under the photo.
+	var existingComment int
+	err = db.c.QueryRow("SELECT 1 FROM comments WHERE commentid = ? AND photoid = ?", commentID, photoID).Scan(&existingComment)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, sql.ErrNoRows // Comment not found
+	} else if err != nil {
+		return nil, fmt.Errorf("error checking existing comment: %w", err)
+	}
+
+	var revisions []CommentRevision
+	rows, err := db.c.Query("SELECT revisionid, commentid, commentText, revisedAt FROM comment_revisions WHERE commentid = ? ORDER BY revisionid", commentID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching comment revisions: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	// Iterate over the rows to extract each revision's data.
+	for rows.Next() {
+		var revision CommentRevision
+		if err := rows.Scan(&revision.RevisionID, &revision.CommentID, &revision.CommentText, &revision.RevisedAt); err != nil {
+			return nil, fmt.Errorf("error scanning comment revision row: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over comment revision rows: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// SetCommentHidden hides a comment pending review, or makes a hidden comment visible again.
+// Only the owner of the photo can hide the comments under it.
+func (db *appdbimpl) SetCommentHidden(userID, photoID, commentID int, hidden bool) error {
+	// Check if the photo exists and belongs to the user.
+	photoAuthorID, err := db.GetPhotoUserID(photoID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sql.ErrNoRows // Photo not found
+	} else if err != nil {
+		return fmt.Errorf("error checking existing photo: %w", err)
+	}
+
+	if photoAuthorID != userID {
+		return errors.New("cannot moderate comments on photos not published by you")
+	}
+
+	// Update the comment visibility, its photo's number of comments and the moderation log together. The visibility is
+	// only updated if it changes, so that repeated requests are not logged.
+	return db.withTx(func(tx *appdbimpl) error {
+		// Check if the comment exists.
+		var currentlyHidden bool
+		err := tx.c.QueryRow("SELECT hidden FROM comments WHERE commentid = ? AND photoid = ?", commentID, photoID).Scan(&currentlyHidden)
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows // Comment not found
+		} else if err != nil {
+			return fmt.Errorf("error checking existing comment: %w", err)
+		}
+
+		result, err := tx.c.Exec("UPDATE comments SET hidden = ? WHERE commentid = ? AND hidden != ?", hidden, commentID, hidden)
+		if err != nil {
+			return fmt.Errorf("error updating comment visibility in database: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error updating comment visibility in database: %w", err)
+		}
+		if affected == 0 {
+			if hidden {
+				return errors.New("comment already hidden")
+			}
+			return errors.New("comment is not hidden")
+		}
+
+		// Hidden comments are not included in the number of comments on the photo.
+		action := "unhide"
+		countChange := 1
+		if hidden {
+			action = "hide"
+			countChange = -1
+		}
+		_, err = tx.c.Exec("UPDATE photos SET commentsCount = commentsCount + ? WHERE photoid = ?", countChange, photoID)
+		if err != nil {
+			return fmt.Errorf("error updating commentsCount in database: %w", err)
+		}
+
+		return tx.logCommentModeration(userID, photoID, commentID, action)
+	})
+}
+
+// SetCommentsEnabled turns comments on or off for the specified photo.
+func (db *appdbimpl) SetCommentsEnabled(userID, photoID int, enabled bool) error {
+	// Check if the photo exists and belongs to the user.
+	photoAuthorID, err := db.GetPhotoUserID(photoID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sql.ErrNoRows // Photo not found
+	} else if err != nil {
+		return fmt.Errorf("error checking existing photo: %w", err)
+	}
+
+	if photoAuthorID != userID {
+		return errors.New("cannot change settings of photos not published by you")
+	}
+
+	// Update the setting and the moderation log together. Nothing is logged if the setting does not change.
+	return db.withTx(func(tx *appdbimpl) error {
+		result, err := tx.c.Exec("UPDATE photos SET commentsEnabled = ? WHERE photoid = ? AND commentsEnabled != ?", enabled, photoID, enabled)
+		if err != nil {
+			return fmt.Errorf("error updating commentsEnabled in database: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error updating commentsEnabled in database: %w", err)
+		}
+		if affected == 0 {
+			return nil
+		}
+
+		action := "disable-comments"
+		if enabled {
+			action = "enable-comments"
+		}
+		return tx.logCommentModeration(userID, photoID, 0, action)
+	})
+}
+
+// logCommentModeration records a moderation action taken by a photo owner on their photo.
+// commentID is 0 for actions concerning the photo as a whole.
+func (db *appdbimpl) logCommentModeration(moderatorID, photoID, commentID int, action string) error {
+	comment := sql.NullInt64{Int64: int64(commentID), Valid: commentID != 0}
+	_, err := db.c.Exec("INSERT INTO comment_moderation (photoid, commentid, moderatorid, action, actionDate) VALUES (?, ?, ?, ?, ?)",
+		photoID, comment, moderatorID, action, time.Now())
+	if err != nil {
+		return fmt.Errorf("error recording comment moderation: %w", err)
+	}
+	return nil
+}
+
+// GetPhoto returns a single photo published by the specified user, with its likes and comments as seen by the viewer.
+// A photo published by a user who banned, or was banned by, the viewer is reported as not found, as is a photo of a
+// private account the viewer does not follow or of a suspended user.
+func (db *appdbimpl) GetPhoto(viewerID, ownerID, photoID int) (CompletePhoto, error) {
+	var photo CompletePhoto
+
+	err := db.c.QueryRow("SELECT photoid, userid, username, imageData, uploadDate, likesCount, commentsCount, commentsEnabled FROM photos WHERE photoid = ? AND userid = ?", photoID, ownerID).
+		Scan(&photo.PhotoID, &photo.UserID, &photo.Username, &photo.ImageData, &photo.UploadDate, &photo.LikesCount, &photo.CommentsCount, &photo.CommentsEnabled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return photo, sql.ErrNoRows // Photo not found
+	} else if err != nil {
+		return photo, fmt.Errorf("error fetching photo: %w", err)
+	}
+
+	// Do not reveal the photo if there is a ban between the viewer and its owner.
+	if err := db.checkBan(viewerID, ownerID); errors.Is(err, ErrBannedByUser) || errors.Is(err, ErrUserBanned) {
+		return CompletePhoto{}, sql.ErrNoRows
+	} else if err != nil {
+		return CompletePhoto{}, err
+	}
+
+	// Photos of private accounts are only visible to their approved followers.
+	if err := db.checkPrivacy(viewerID, ownerID); errors.Is(err, ErrPrivateAccount) {
+		return CompletePhoto{}, sql.ErrNoRows
+	} else if err != nil {
+		return CompletePhoto{}, err
+	}
+
+	// Photos of suspended users are hidden.
+	if err := db.checkSuspended(ownerID); errors.Is(err, ErrUserSuspended) {
+		return CompletePhoto{}, sql.ErrNoRows
+	} else if err != nil {
+		return CompletePhoto{}, err
+	}
+
+	if err := db.getPhotoDetails(viewerID, &photo); err != nil {
+		return CompletePhoto{}, err
+	}
+
+	return photo, nil
+}
+
+// GetPhotoLikes returns a page of the likes on the specified photo, including the username of each user who liked it.
+// Likes from users who banned the viewer, or who were banned by the viewer, and from suspended users are not returned.
+func (db *appdbimpl) GetPhotoLikes(viewerID, ownerID, photoID, limit, offset int) ([]Like, error) {
+	// Check if the photo exists and was published by the owner.
+	photoAuthorID, err := db.GetPhotoUserID(photoID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && photoAuthorID != ownerID) {
+		return nil, sql.ErrNoRows // Photo not found
+	} else if err != nil {
+		return nil, fmt.Errorf("error checking existing photo: %w", err)
+	}
+
+	// Do not reveal the photo if there is a ban between the viewer and the user who posted it.
+	if err := db.checkBan(viewerID, photoAuthorID); errors.Is(err, ErrBannedByUser) || errors.Is(err, ErrUserBanned) {
+		return nil, sql.ErrNoRows
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Photos of private accounts are only visible to their approved followers.
+	if err := db.checkPrivacy(viewerID, photoAuthorID); errors.Is(err, ErrPrivateAccount) {
+		return nil, sql.ErrNoRows
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Photos of suspended users are hidden.
+	if err := db.checkSuspended(photoAuthorID); errors.Is(err, ErrUserSuspended) {
+		return nil, sql.ErrNoRows
+	} else if err != nil {
+		return nil, err
+	}
+
+	var likes []Like
+	rows, err := db.c.Query(`SELECT l.likeid, l.userid, u.username, l.photoid
+		FROM likes l JOIN users u ON l.userid = u.userid
+		WHERE l.photoid = ? AND `+notBannedCondition("l.userid")+` AND `+notSuspendedCondition("l.userid")+`
+		ORDER BY l.likeid LIMIT ? OFFSET ?`, photoID, viewerID, viewerID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching likes: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	// Iterate over the rows to extract each like's data.
+	for rows.Next() {
+		var like Like
+		if err := rows.Scan(&like.LikeID, &like.UserID, &like.Username, &like.PhotoID); err != nil {
+			return nil, fmt.Errorf("error scanning like row: %w", err)
+		}
+		likes = append(likes, like)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over likes rows: %w", err)
+	}
+
+	return likes, nil
+}
+
+// GetPhotoComments returns a page of the comments on the specified photo, oldest first.
+// Comments from users who banned the viewer, or who were banned or muted by the viewer, and from suspended users are not
+// returned.
+func (db *appdbimpl) GetPhotoComments(viewerID, ownerID, photoID, limit, offset int) ([]Comment, error) {
+	// Check if the photo exists and was published by the owner.
+	photoAuthorID, err := db.GetPhotoUserID(photoID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && photoAuthorID != ownerID) {
+		return nil, sql.ErrNoRows // Photo not found
+	} else if err != nil {
+		return nil, fmt.Errorf("error checking existing photo: %w", err)
+	}
+
+	// Do not reveal the photo if there is a ban between the viewer and the user who posted it.
+	if err := db.checkBan(viewerID, photoAuthorID); errors.Is(err, ErrBannedByUser) || errors.Is(err, ErrUserBanned) {
+		return nil, sql.ErrNoRows
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Photos of private accounts are only visible to their approved followers.
+	if err := db.checkPrivacy(viewerID, photoAuthorID); errors.Is(err, ErrPrivateAccount) {
+		return nil, sql.ErrNoRows
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Photos of suspended users are hidden.
+	if err := db.checkSuspended(photoAuthorID); errors.Is(err, ErrUserSuspended) {
+		return nil, sql.ErrNoRows
+	} else if err != nil {
+		return nil, err
+	}
+
+	var comments []Comment
+	rows, err := db.c.Query(`SELECT c.commentid, c.userid, u.username, c.photoid, c.commentText, c.uploadDate, c.editedAt, c.hidden
+		FROM comments c JOIN users u ON c.userid = u.userid
+		WHERE c.photoid = ? AND (c.hidden = 0 OR c.userid = ? OR ? = ?)
+		AND `+notBannedCondition("c.userid")+` AND `+notMutedCondition("c.userid")+` AND `+notSuspendedCondition("c.userid")+`
+		ORDER BY c.uploadDate, c.commentid LIMIT ? OFFSET ?`, photoID, viewerID, photoAuthorID, viewerID, viewerID, viewerID, viewerID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching comments: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	// Iterate over the rows to extract each comment's data.
+	for rows.Next() {
+		var comment Comment
+		var editedAt sql.NullTime
+		if err := rows.Scan(&comment.CommentID, &comment.AuthorID, &comment.AuthorUsername, &comment.PhotoID, &comment.CommentText, &comment.UploadDate, &editedAt, &comment.Hidden); err != nil {
+			return nil, fmt.Errorf("error scanning comment row: %w", err)
+		}
+		if editedAt.Valid {
+			comment.EditedAt = &editedAt.Time
+		}
+		comments = append(comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over comment rows: %w", err)
+	}
+
+	return comments, nil
+}
+
 // DeletePhoto removes a photo.
 func (db *appdbimpl) DeletePhoto(userID, photoID int) error {
 
@@ -222,8 +693,20 @@ func (db *appdbimpl) DeletePhoto(userID, photoID int) error {
 		return errors.New("cannot delete photos not published by you")
 	}
 
+	// Remove the photo and record the removal together.
+	return db.withTx(func(tx *appdbimpl) error {
+		if err := tx.removePhoto(photoID); err != nil {
+			return err
+		}
+
+		return tx.logAuditEvent(userID, AuditPhotoDelete, AuditTargetPhoto, photoID, "")
+	})
+}
+
+// removePhoto deletes a photo with its likes and comments, without any permission check.
+func (db *appdbimpl) removePhoto(photoID int) error {
 	// Remove the photo from the photos table.
-	_, err = db.c.Exec("DELETE FROM photos WHERE photoid = ?", photoID)
+	_, err := db.c.Exec("DELETE FROM photos WHERE photoid = ?", photoID)
 	if err != nil {
 		return fmt.Errorf("error removing photo from database: %w", err)
 	}
@@ -234,11 +717,35 @@ func (db *appdbimpl) DeletePhoto(userID, photoID int) error {
 		return fmt.Errorf("error removing photo's likes from database: %w", err)
 	}
 
+	// Remove the edit history of the comments associated with this photo.
+	_, err = db.c.Exec("DELETE FROM comment_revisions WHERE commentid IN (SELECT commentid FROM comments WHERE photoid = ?)", photoID)
+	if err != nil {
+		return fmt.Errorf("error removing photo's comment revisions from database: %w", err)
+	}
+
 	// Remove comments associated with this photo.
 	_, err = db.c.Exec("DELETE FROM comments WHERE photoid = ?", photoID)
 	if err != nil {
 		return fmt.Errorf("error removing photo's comments from database: %w", err)
 	}
 
+	// Remove the notifications about this photo.
+	_, err = db.c.Exec("DELETE FROM notifications WHERE photoid = ?", photoID)
+	if err != nil {
+		return fmt.Errorf("error removing photo's notifications from database: %w", err)
+	}
+
+	// Remove the photo from the timelines.
+	_, err = db.c.Exec("DELETE FROM timelines WHERE photoid = ?", photoID)
+	if err != nil {
+		return fmt.Errorf("error removing photo from timelines: %w", err)
+	}
+
+	// Remove the photo from the collections it was saved to.
+	_, err = db.c.Exec("DELETE FROM collection_items WHERE photoid = ?", photoID)
+	if err != nil {
+		return fmt.Errorf("error removing photo from collections: %w", err)
+	}
+
 	return nil
 }
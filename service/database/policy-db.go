@@ -0,0 +1,187 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Ban policy.
+// A ban always applies in both directions: once either user banned the other, they can no longer interact (follow,
+// like, comment) and the content of each one is hidden from the other. Every read and write path involving two users
+// must go through the helpers below instead of querying banned_users directly. Expired temporary bans are ignored,
+// since the helpers only read the active_bans view.
+
+var (
+	// ErrBannedByUser is returned when the other user banned the acting user.
+	ErrBannedByUser = errors.New("you are banned by this user")
+
+	// ErrUserBanned is returned when the acting user banned the other user.
+	ErrUserBanned = errors.New("you have banned this user")
+
+	// ErrPrivateAccount is returned when the other user has a private account and the acting user does not follow them.
+	ErrPrivateAccount = errors.New("this account is private")
+
+	// ErrUserSuspended is returned when the user has been suspended by an admin.
+	ErrUserSuspended = errors.New("this account has been suspended")
+)
+
+// checkBan returns ErrBannedByUser or ErrUserBanned if there is a ban between the two users, in either direction.
+// If both users banned each other, ErrBannedByUser is returned.
+func (db *appdbimpl) checkBan(actorID, otherID int) error {
+	var bannedBy bool
+	err := db.c.QueryRow(`SELECT userid = ? FROM active_bans
+		WHERE (userid = ? AND banneduserid = ?) OR (userid = ? AND banneduserid = ?)
+		ORDER BY userid = ? DESC LIMIT 1`, otherID, otherID, actorID, actorID, otherID, otherID).Scan(&bannedBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error checking ban status: %w", err)
+	}
+
+	if bannedBy {
+		return ErrBannedByUser
+	}
+	return ErrUserBanned
+}
+
+// notBannedCondition returns an SQL condition excluding the rows whose user, identified by the given column, banned
+// or was banned by the viewer. The viewer ID must be bound twice, once for each direction.
+func notBannedCondition(column string) string {
+	return fmt.Sprintf("%[1]s NOT IN (SELECT userid FROM active_bans WHERE banneduserid = ?) AND %[1]s NOT IN (SELECT banneduserid FROM active_bans WHERE userid = ?)", column)
+}
+
+// getBannedRelations returns the set of users who banned, or were banned by, the specified user.
+// It is used to filter lists that are already loaded in memory.
+func (db *appdbimpl) getBannedRelations(userID int) (map[int]bool, error) {
+	rows, err := db.c.Query(`SELECT userid FROM active_bans WHERE banneduserid = ?
+		UNION SELECT banneduserid FROM active_bans WHERE userid = ?`, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching banned relations: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	banned := make(map[int]bool)
+	for rows.Next() {
+		var otherID int
+		if err := rows.Scan(&otherID); err != nil {
+			return nil, fmt.Errorf("error scanning banned relation row: %w", err)
+		}
+		banned[otherID] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over banned relation rows: %w", err)
+	}
+
+	return banned, nil
+}
+
+// excludeUsers removes from the list the users in the excluded set, such as the one returned by getBannedRelations.
+func excludeUsers(users []User, excluded map[int]bool) []User {
+	var visible []User
+	for _, user := range users {
+		if !excluded[user.UserID] {
+			visible = append(visible, user)
+		}
+	}
+	return visible
+}
+
+// Mute policy.
+// Muting is one-directional and invisible to the muted user: the viewer keeps following them, but their photos are
+// left out of the viewer's stream and their comments are left out of the photos shown to the viewer.
+
+// notMutedCondition returns an SQL condition excluding the rows whose user, identified by the given column, was muted
+// by the viewer. The viewer ID must be bound once.
+func notMutedCondition(column string) string {
+	return fmt.Sprintf("%s NOT IN (SELECT muteduserid FROM muted_users WHERE userid = ?)", column)
+}
+
+// HasMuted reports whether the user muted the other user.
+func (db *appdbimpl) HasMuted(userID, otherID int) (bool, error) {
+	var muted bool
+	err := db.c.QueryRow("SELECT EXISTS (SELECT 1 FROM muted_users WHERE userid = ? AND muteduserid = ?)", userID, otherID).Scan(&muted)
+	if err != nil {
+		return false, fmt.Errorf("error checking mute status: %w", err)
+	}
+	return muted, nil
+}
+
+// getMutedRelations returns the set of users muted by the specified user.
+func (db *appdbimpl) getMutedRelations(userID int) (map[int]bool, error) {
+	rows, err := db.c.Query("SELECT muteduserid FROM muted_users WHERE userid = ?", userID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching muted users: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	muted := make(map[int]bool)
+	for rows.Next() {
+		var otherID int
+		if err := rows.Scan(&otherID); err != nil {
+			return nil, fmt.Errorf("error scanning muted user row: %w", err)
+		}
+		muted[otherID] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over muted user rows: %w", err)
+	}
+
+	return muted, nil
+}
+
+// Privacy policy.
+// The photos of a private account, with their likes and comments, are only visible to the owner and to the followers
+// they approved. Other users only see the basic details of the profile, and can ask to follow it.
+
+// checkPrivacy returns ErrPrivateAccount if the owner has a private account and the viewer is neither the owner nor
+// one of their followers.
+func (db *appdbimpl) checkPrivacy(viewerID, ownerID int) error {
+	if viewerID == ownerID {
+		return nil
+	}
+
+	var visible bool
+	err := db.c.QueryRow(`SELECT private = 0 OR EXISTS (SELECT 1 FROM followers WHERE userid = users.userid AND followerid = ?)
+		FROM users WHERE userid = ?`, viewerID, ownerID).Scan(&visible)
+	if err != nil {
+		return fmt.Errorf("error checking account privacy: %w", err)
+	}
+
+	if !visible {
+		return ErrPrivateAccount
+	}
+	return nil
+}
+
+// visibleAccountCondition returns an SQL condition excluding the rows whose user, identified by the given column, has
+// a private account the viewer neither owns nor follows. The viewer ID must be bound twice.
+func visibleAccountCondition(column string) string {
+	return fmt.Sprintf("(%[1]s IN (SELECT userid FROM users WHERE private = 0) OR %[1]s = ? OR %[1]s IN (SELECT userid FROM followers WHERE followerid = ?))", column)
+}
+
+// Suspension policy.
+// Suspended users cannot log in, and disappear from the platform: their profile and photos are reported as not found,
+// and they are left out of streams, searches, followers and following lists, and comments.
+
+// checkSuspended returns ErrUserSuspended if the user has been suspended.
+func (db *appdbimpl) checkSuspended(userID int) error {
+	var suspended bool
+	err := db.c.QueryRow("SELECT suspended FROM users WHERE userid = ?", userID).Scan(&suspended)
+	if err != nil {
+		return fmt.Errorf("error checking suspension: %w", err)
+	}
+
+	if suspended {
+		return ErrUserSuspended
+	}
+	return nil
+}
+
+// notSuspendedCondition returns an SQL condition excluding the rows whose user, identified by the given column, has
+// been suspended.
+func notSuspendedCondition(column string) string {
+	return fmt.Sprintf("%s NOT IN (SELECT userid FROM users WHERE suspended = 1)", column)
+}
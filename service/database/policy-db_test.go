@@ -0,0 +1,235 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// policyFixture holds a fresh database with a viewer, the owner of a photo, and a third user whose photo the owner
+// liked and commented.
+type policyFixture struct {
+	db                  *appdbimpl
+	viewer, owner, peer int
+	photo, peerPhoto    int
+}
+
+// newPolicyFixture creates an in-memory database with the users and photos of a policy test.
+func newPolicyFixture(t *testing.T) *policyFixture {
+	t.Helper()
+
+	// Every connection of the pool must share the same in-memory database.
+	conn, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	appdb, err := New(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &policyFixture{db: appdb.(*appdbimpl)}
+
+	for _, u := range []struct {
+		id       *int
+		username string
+	}{{&f.viewer, "viewer"}, {&f.owner, "owner"}, {&f.peer, "peer"}} {
+		user, err := f.db.CreateUser(User{Username: u.username})
+		if err != nil {
+			t.Fatal(err)
+		}
+		*u.id = user.UserID
+	}
+
+	f.photo = f.upload(t, f.owner, "owner")
+	f.peerPhoto = f.upload(t, f.peer, "peer")
+
+	if err := f.db.LikePhoto(f.owner, f.peerPhoto, Like{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.db.CommentPhoto(f.owner, f.peerPhoto, "owner", Comment{CommentText: "Nice one", UploadDate: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// upload stores a photo of the user, returning its ID.
+func (f *policyFixture) upload(t *testing.T, userID int, username string) int {
+	t.Helper()
+	p, err := f.db.CreatePhoto(Photo{UserID: userID, Username: username, ImageData: []byte{0}, UploadDate: time.Now(), CommentsEnabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.PhotoID
+}
+
+// refused reports whether err is one of the errors returned when the policy hides content or refuses an action.
+// Other errors are returned, to fail the test.
+func refused(err error) (bool, error) {
+	for _, policyErr := range []error{ErrBannedByUser, ErrUserBanned, ErrPrivateAccount, ErrUserSuspended, sql.ErrNoRows} {
+		if errors.Is(err, policyErr) {
+			return true, nil
+		}
+	}
+	return false, err
+}
+
+// TestPolicy checks that the policy is applied on every read and write path involving the viewer and the owner.
+func TestPolicy(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	relations := []struct {
+		name  string
+		apply func(*policyFixture) error
+	}{
+		{"no relation", func(f *policyFixture) error { return nil }},
+		{"viewer bans owner", func(f *policyFixture) error {
+			return f.db.BanUser(f.viewer, f.owner, Ban{CreatedAt: time.Now()})
+		}},
+		{"owner bans viewer", func(f *policyFixture) error {
+			return f.db.BanUser(f.owner, f.viewer, Ban{CreatedAt: time.Now()})
+		}},
+		{"expired ban", func(f *policyFixture) error {
+			return f.db.BanUser(f.owner, f.viewer, Ban{CreatedAt: past.Add(-time.Hour), ExpiresAt: &past})
+		}},
+		{"private account", func(f *policyFixture) error {
+			return f.db.SetPrivate(f.owner, true)
+		}},
+		{"suspended owner", func(f *policyFixture) error {
+			return f.db.SetUserSuspended(f.peer, f.owner, true)
+		}},
+		{"muted owner", func(f *policyFixture) error {
+			return f.db.MuteUser(f.viewer, f.owner)
+		}},
+	}
+
+	// Each check reports whether the viewer could see the owner's content, or act on it.
+	checks := []struct {
+		name  string
+		check func(*policyFixture) (bool, error)
+	}{
+		{"profile", func(f *policyFixture) (bool, error) {
+			profile, err := f.db.GetUserProfile(f.viewer, f.owner)
+			if err != nil {
+				_, err = refused(err)
+				return false, err
+			}
+			return len(profile.UploadedPhotos) > 0, nil
+		}},
+		{"stream", func(f *policyFixture) (bool, error) {
+			// The follow may be refused, or left pending, by the relation.
+			if _, err := f.db.FollowUser(f.viewer, f.owner); err != nil {
+				if _, err := refused(err); err != nil {
+					return false, err
+				}
+			}
+
+			stream, err := f.db.GetMyStream(f.viewer, time.Time{})
+			if err != nil {
+				return false, err
+			}
+			for _, p := range stream {
+				if p.PhotoID == f.photo {
+					return true, nil
+				}
+			}
+			return false, nil
+		}},
+		{"search", func(f *policyFixture) (bool, error) {
+			users, err := f.db.GetUsers(f.viewer, "own")
+			if err != nil {
+				return false, err
+			}
+			for _, u := range users {
+				if u.UserID == f.owner {
+					return true, nil
+				}
+			}
+			return false, nil
+		}},
+		{"likes", func(f *policyFixture) (bool, error) {
+			likes, err := f.db.GetPhotoLikes(f.viewer, f.peer, f.peerPhoto, 100, 0)
+			if err != nil {
+				return false, err
+			}
+			for _, l := range likes {
+				if l.UserID == f.owner {
+					return true, nil
+				}
+			}
+			return false, nil
+		}},
+		{"comments", func(f *policyFixture) (bool, error) {
+			comments, err := f.db.GetPhotoComments(f.viewer, f.peer, f.peerPhoto, 100, 0)
+			if err != nil {
+				return false, err
+			}
+			for _, c := range comments {
+				if c.AuthorID == f.owner {
+					return true, nil
+				}
+			}
+			return false, nil
+		}},
+		{"follow", func(f *policyFixture) (bool, error) {
+			pending, err := f.db.FollowUser(f.viewer, f.owner)
+			if err != nil {
+				_, err = refused(err)
+				return false, err
+			}
+			return !pending, nil
+		}},
+		{"like", func(f *policyFixture) (bool, error) {
+			err := f.db.LikePhoto(f.viewer, f.photo, Like{})
+			if err != nil {
+				_, err = refused(err)
+				return false, err
+			}
+			return true, nil
+		}},
+		{"comment", func(f *policyFixture) (bool, error) {
+			_, err := f.db.CommentPhoto(f.viewer, f.photo, "viewer", Comment{CommentText: "Great shot", UploadDate: time.Now()})
+			if err != nil {
+				_, err = refused(err)
+				return false, err
+			}
+			return true, nil
+		}},
+	}
+
+	// Expected outcome of each check, in the order above, for each relation.
+	expected := map[string][]bool{
+		//                   profile stream search likes  comments follow like   comment
+		"no relation":       {true, true, true, true, true, true, true, true},
+		"viewer bans owner": {false, false, false, false, false, false, false, false},
+		"owner bans viewer": {false, false, false, false, false, false, false, false},
+		"expired ban":       {true, true, true, true, true, true, true, true},
+		"private account":   {false, false, true, true, true, false, false, false},
+		"suspended owner":   {false, false, false, false, false, false, false, false},
+		"muted owner":       {true, false, true, true, false, true, true, true},
+	}
+
+	for _, relation := range relations {
+		for i, check := range checks {
+			relation, check, want := relation, check, expected[relation.name][i]
+			t.Run(relation.name+"/"+check.name, func(t *testing.T) {
+				f := newPolicyFixture(t)
+				if err := relation.apply(f); err != nil {
+					t.Fatal(err)
+				}
+
+				got, err := check.check(f)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Errorf("got %v, want %v", got, want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Kinds of events the users can set their notification preferences for.
+const (
+	PreferenceLikes    = "likes"    // Likes on the user's photos
+	PreferenceComments = "comments" // Comments on the user's photos
+	PreferenceFollows  = "follows"  // New followers and follow requests
+	PreferenceMentions = "mentions" // Mentions in comments
+)
+
+// preferenceEvents maps the types of notifications to the kind of event governing them.
+var preferenceEvents = map[string]string{
+	NotificationLike:          PreferenceLikes,
+	NotificationComment:       PreferenceComments,
+	NotificationFollow:        PreferenceFollows,
+	NotificationFollowRequest: PreferenceFollows,
+	NotificationMention:       PreferenceMentions,
+}
+
+// defaultNotificationChannels are the channels of the events the user did not set preferences for.
+var defaultNotificationChannels = NotificationChannels{InApp: true, Push: true, Email: true}
+
+// GetNotificationPreferences returns the notification preferences of the specified user, with every kind of event.
+func (db *appdbimpl) GetNotificationPreferences(userID int) (NotificationPreferences, error) {
+	preferences := NotificationPreferences{
+		Events: map[string]NotificationChannels{
+			PreferenceLikes:    defaultNotificationChannels,
+			PreferenceComments: defaultNotificationChannels,
+			PreferenceFollows:  defaultNotificationChannels,
+			PreferenceMentions: defaultNotificationChannels,
+		},
+	}
+
+	// Check if the user exists.
+	var pausedUntil sql.NullTime
+	err := db.c.QueryRow("SELECT notificationsPausedUntil FROM users WHERE userid = ?", userID).Scan(&pausedUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return preferences, sql.ErrNoRows // User not found
+	} else if err != nil {
+		return preferences, fmt.Errorf("error checking existing user: %w", err)
+	}
+	if pausedUntil.Valid && pausedUntil.Time.After(time.Now()) {
+		preferences.PausedUntil = &pausedUntil.Time
+	}
+
+	rows, err := db.c.Query("SELECT event, inApp, push, email FROM notification_preferences WHERE userid = ?", userID)
+	if err != nil {
+		return preferences, fmt.Errorf("error fetching notification preferences: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	for rows.Next() {
+		var event string
+		var channels NotificationChannels
+		if err := rows.Scan(&event, &channels.InApp, &channels.Push, &channels.Email); err != nil {
+			return preferences, fmt.Errorf("error scanning notification preference row: %w", err)
+		}
+		preferences.Events[event] = channels
+	}
+
+	if err := rows.Err(); err != nil {
+		return preferences, fmt.Errorf("error iterating over notification preference rows: %w", err)
+	}
+
+	return preferences, nil
+}
+
+// SetNotificationPreferences replaces the notification preferences of the specified user. Kinds of events missing
+// from the preferences are reset to the defaults. sql.ErrNoRows is returned if the user does not exist.
+func (db *appdbimpl) SetNotificationPreferences(userID int, preferences NotificationPreferences) error {
+	for event := range preferences.Events {
+		if !isPreferenceEvent(event) {
+			return fmt.Errorf("invalid notification event %q", event)
+		}
+	}
+
+	var pausedUntil sql.NullTime
+	if preferences.PausedUntil != nil {
+		pausedUntil = sql.NullTime{Time: *preferences.PausedUntil, Valid: true}
+	}
+
+	// Replace the pause and the preferences together.
+	return db.withTx(func(tx *appdbimpl) error {
+		result, err := tx.c.Exec("UPDATE users SET notificationsPausedUntil = ? WHERE userid = ?", pausedUntil, userID)
+		if err != nil {
+			return fmt.Errorf("error updating notification pause: %w", err)
+		}
+
+		// Check if the user actually exists.
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error updating notification pause: %w", err)
+		}
+		if affected == 0 {
+			return sql.ErrNoRows
+		}
+
+		_, err = tx.c.Exec("DELETE FROM notification_preferences WHERE userid = ?", userID)
+		if err != nil {
+			return fmt.Errorf("error removing notification preferences: %w", err)
+		}
+
+		for event, channels := range preferences.Events {
+			if channels == defaultNotificationChannels {
+				continue
+			}
+			_, err = tx.c.Exec("INSERT INTO notification_preferences (userid, event, inApp, push, email) VALUES (?, ?, ?, ?, ?)",
+				userID, event, channels.InApp, channels.Push, channels.Email)
+			if err != nil {
+				return fmt.Errorf("error inserting notification preference: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetNotificationChannels returns the channels through which the specified type of notification, or of real-time
+// event, reaches the user. No channel is returned while the notifications of the user are paused.
+func (db *appdbimpl) GetNotificationChannels(userID int, notificationType string) (NotificationChannels, error) {
+	var channels NotificationChannels
+
+	var pausedUntil sql.NullTime
+	err := db.c.QueryRow("SELECT notificationsPausedUntil FROM users WHERE userid = ?", userID).Scan(&pausedUntil)
+	if err != nil {
+		return channels, fmt.Errorf("error checking notification pause: %w", err)
+	}
+	if pausedUntil.Valid && pausedUntil.Time.After(time.Now()) {
+		return channels, nil
+	}
+
+	// Events without preferences, such as the photos of the followed users, use every channel.
+	event, ok := preferenceEvents[notificationType]
+	if !ok {
+		return defaultNotificationChannels, nil
+	}
+
+	err = db.c.QueryRow("SELECT inApp, push, email FROM notification_preferences WHERE userid = ? AND event = ?",
+		userID, event).Scan(&channels.InApp, &channels.Push, &channels.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return defaultNotificationChannels, nil
+	} else if err != nil {
+		return channels, fmt.Errorf("error fetching notification preference: %w", err)
+	}
+	return channels, nil
+}
+
+// isPreferenceEvent checks if the users can set their notification preferences for the kind of event.
+func isPreferenceEvent(event string) bool {
+	for _, e := range preferenceEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// GetRelationships returns how the specified user is related to each of the other users, in the same order.
+// Users that do not exist or are suspended are left out; sql.ErrNoRows is returned if none of them is left.
+// Each kind of relation is loaded with a single query for all the users.
+func (db *appdbimpl) GetRelationships(userID int, otherIDs []int) ([]Relationship, error) {
+	if len(otherIDs) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(otherIDs)), ", ")
+	otherArgs := make([]interface{}, len(otherIDs))
+	for i, otherID := range otherIDs {
+		otherArgs[i] = otherID
+	}
+
+	existing, err := db.getUserSet("SELECT userid FROM users WHERE userid IN ("+placeholders+") AND "+notSuspendedCondition("userid"),
+		otherArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	var relationships []Relationship
+	for _, otherID := range otherIDs {
+		if existing[otherID] && otherID != userID {
+			relationships = append(relationships, Relationship{UserID: otherID})
+			existing[otherID] = false // Skip duplicates
+		}
+	}
+	if len(relationships) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	// Every query selects, among the other users, the ones having that relation with the specified user.
+	relations := []struct {
+		query string
+		set   func(*Relationship)
+	}{
+		{"SELECT userid FROM followers WHERE followerid = ? AND userid IN", func(r *Relationship) { r.Following = true }},
+		{"SELECT followerid FROM followers WHERE userid = ? AND followerid IN", func(r *Relationship) { r.FollowedBy = true }},
+		{"SELECT banneduserid FROM active_bans WHERE userid = ? AND banneduserid IN", func(r *Relationship) { r.Banned = true }},
+		{"SELECT userid FROM active_bans WHERE banneduserid = ? AND userid IN", func(r *Relationship) { r.BannedBy = true }},
+		{"SELECT muteduserid FROM muted_users WHERE userid = ? AND muteduserid IN", func(r *Relationship) { r.Muted = true }},
+		{"SELECT userid FROM follow_requests WHERE requesterid = ? AND userid IN", func(r *Relationship) { r.FollowRequested = true }},
+		{"SELECT requesterid FROM follow_requests WHERE userid = ? AND requesterid IN", func(r *Relationship) { r.FollowRequestReceived = true }},
+	}
+
+	args := append([]interface{}{userID}, otherArgs...)
+	for _, relation := range relations {
+		related, err := db.getUserSet(relation.query+" ("+placeholders+")", args...)
+		if err != nil {
+			return nil, err
+		}
+		for i := range relationships {
+			if related[relationships[i].UserID] {
+				relation.set(&relationships[i])
+			}
+		}
+	}
+
+	return relationships, nil
+}
+
+// getUserSet runs a query selecting a single column of user IDs, and returns them as a set.
+func (db *appdbimpl) getUserSet(query string, args ...interface{}) (map[int]bool, error) {
+	rows, err := db.c.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching relationships: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	users := make(map[int]bool)
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("error scanning relationship row: %w", err)
+		}
+		users[userID] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over relationship rows: %w", err)
+	}
+
+	return users, nil
+}
@@ -0,0 +1,279 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Types of content that can be reported.
+const (
+	ReportTargetPhoto   = "photo"
+	ReportTargetComment = "comment"
+	ReportTargetUser    = "user"
+)
+
+// Statuses of a report.
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+// Decisions a moderator can take on a report.
+const (
+	ReportActionRemove  = "remove"  // Remove the reported photo or comment
+	ReportActionSuspend = "suspend" // Suspend the user who published the reported content, or the reported user
+	ReportActionDismiss = "dismiss" // Take no action
+)
+
+// ErrStaffSuspension is returned when a moderator decides to suspend another moderator or an admin: only admins can.
+var ErrStaffSuspension = errors.New("only admins can suspend moderators and admins")
+
+// CreateReport flags a photo, a comment or a user as abusive, adding it to the moderation queue.
+func (db *appdbimpl) CreateReport(r Report) (Report, error) {
+	// Check if the reported content exists.
+	ownerID, err := db.getReportTargetOwner(r.TargetType, r.TargetID)
+	if err != nil {
+		return r, err
+	}
+
+	if ownerID == r.ReporterID {
+		return r, errors.New("cannot report your own content")
+	}
+
+	// Check if the user already reported the same content, and the report is still waiting for a decision.
+	var existingReport int
+	err = db.c.QueryRow("SELECT 1 FROM reports WHERE reporterid = ? AND targetType = ? AND targetid = ? AND status = ?",
+		r.ReporterID, r.TargetType, r.TargetID, ReportStatusOpen).Scan(&existingReport)
+	if err == nil {
+		return r, errors.New("already reported")
+	}
+
+	// Store the report and its webhook event together.
+	err = db.withTx(func(tx *appdbimpl) error {
+		result, err := tx.c.Exec("INSERT INTO reports (reporterid, targetType, targetid, reason, details, status, createdAt) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			r.ReporterID, r.TargetType, r.TargetID, r.Reason, r.Details, ReportStatusOpen, r.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("error inserting report into database: %w", err)
+		}
+
+		// Get the ID of the newly created report.
+		reportID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		r.ReportID = int(reportID)
+		r.Status = ReportStatusOpen
+
+		return tx.enqueueWebhookEvent(WebhookEventReportCreated, 0, struct {
+			ReportID   int    `json:"reportID"`
+			TargetType string `json:"targetType"`
+			TargetID   int    `json:"targetID"`
+			Reason     string `json:"reason"`
+		}{r.ReportID, r.TargetType, r.TargetID, r.Reason})
+	})
+	return r, err
+}
+
+// GetReports returns a page of the reports with the specified status, oldest first.
+func (db *appdbimpl) GetReports(status string, limit, offset int) ([]Report, error) {
+	var reports []Report
+
+	rows, err := db.c.Query(`SELECT reportid, reporterid, targetType, targetid, reason, details, status, createdAt, resolverid, action, note, resolvedAt
+		FROM reports WHERE status = ? ORDER BY createdAt, reportid LIMIT ? OFFSET ?`, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching reports: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	// Iterate over the rows to extract each report's data.
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over report rows: %w", err)
+	}
+
+	return reports, nil
+}
+
+// ResolveReport applies the moderator's decision to an open report, and records it.
+// Removing the content or suspending its author also resolves the other open reports about the same content.
+func (db *appdbimpl) ResolveReport(moderatorID, reportID int, action, note string) (Report, error) {
+	// Check if the report exists and is still open.
+	report, err := db.getReport(reportID)
+	if err != nil {
+		return report, err
+	}
+
+	if report.Status != ReportStatusOpen {
+		return report, errors.New("the report has already been handled")
+	}
+
+	// The reported content may have been deleted by its author in the meantime: such reports can only be dismissed.
+	ownerID, err := db.getReportTargetOwner(report.TargetType, report.TargetID)
+	if errors.Is(err, sql.ErrNoRows) && action != ReportActionDismiss {
+		return report, errors.New("the reported content no longer exists")
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return report, err
+	}
+
+	// Apply the decision and record it together.
+	err = db.withTx(func(tx *appdbimpl) error {
+		status := ReportStatusResolved
+		switch action {
+		case ReportActionRemove:
+			if err := tx.removeReportTarget(moderatorID, report); err != nil {
+				return err
+			}
+		case ReportActionSuspend:
+			if ownerID == moderatorID {
+				return errors.New("cannot suspend yourself")
+			}
+			if err := tx.checkCanSuspend(moderatorID, ownerID); err != nil {
+				return err
+			}
+			if err := tx.SetUserSuspended(moderatorID, ownerID, true); err != nil {
+				return err
+			}
+		case ReportActionDismiss:
+			status = ReportStatusDismissed
+		default:
+			return fmt.Errorf("unknown action %q", action)
+		}
+
+		// Record the decision. Dismissing a report does not affect the other reports about the same content.
+		query := "UPDATE reports SET status = ?, resolverid = ?, action = ?, note = ?, resolvedAt = ? WHERE reportid = ?"
+		args := []interface{}{status, moderatorID, action, note, time.Now(), reportID}
+		if action != ReportActionDismiss {
+			query += " OR (targetType = ? AND targetid = ? AND status = ?)"
+			args = append(args, report.TargetType, report.TargetID, ReportStatusOpen)
+		}
+		_, err := tx.c.Exec(query, args...)
+		if err != nil {
+			return fmt.Errorf("error recording report decision: %w", err)
+		}
+
+		return tx.logAuditEvent(moderatorID, AuditReportDecision, AuditTargetReport, reportID, action)
+	})
+	if err != nil {
+		return report, err
+	}
+
+	return db.getReport(reportID)
+}
+
+// checkCanSuspend returns ErrStaffSuspension if the user is a moderator or an admin, and the moderator suspending
+// them is not an admin.
+func (db *appdbimpl) checkCanSuspend(moderatorID, userID int) error {
+	role, err := db.GetUserRole(userID)
+	if err != nil {
+		return err
+	}
+	if role == RoleUser {
+		return nil
+	}
+
+	moderatorRole, err := db.GetUserRole(moderatorID)
+	if err != nil {
+		return err
+	}
+	if moderatorRole != RoleAdmin {
+		return ErrStaffSuspension
+	}
+	return nil
+}
+
+// getReport returns a single report.
+func (db *appdbimpl) getReport(reportID int) (Report, error) {
+	row := db.c.QueryRow(`SELECT reportid, reporterid, targetType, targetid, reason, details, status, createdAt, resolverid, action, note, resolvedAt
+		FROM reports WHERE reportid = ?`, reportID)
+	report, err := scanReport(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return report, sql.ErrNoRows // Report not found
+	}
+	return report, err
+}
+
+// scanReport reads a report from a row of the reports table.
+func scanReport(row interface{ Scan(...interface{}) error }) (Report, error) {
+	var report Report
+	var details, action, note sql.NullString
+	var resolverID sql.NullInt64
+	var resolvedAt sql.NullTime
+	err := row.Scan(&report.ReportID, &report.ReporterID, &report.TargetType, &report.TargetID, &report.Reason, &details,
+		&report.Status, &report.CreatedAt, &resolverID, &action, &note, &resolvedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return report, err
+	} else if err != nil {
+		return report, fmt.Errorf("error scanning report row: %w", err)
+	}
+
+	report.Details = details.String
+	report.ResolverID = int(resolverID.Int64)
+	report.Action = action.String
+	report.Note = note.String
+	if resolvedAt.Valid {
+		report.ResolvedAt = &resolvedAt.Time
+	}
+	return report, nil
+}
+
+// getReportTargetOwner returns the ID of the user who published the reported content, or of the reported user.
+// sql.ErrNoRows is returned if the content does not exist.
+func (db *appdbimpl) getReportTargetOwner(targetType string, targetID int) (int, error) {
+	var query string
+	switch targetType {
+	case ReportTargetPhoto:
+		query = "SELECT userid FROM photos WHERE photoid = ?"
+	case ReportTargetComment:
+		query = "SELECT userid FROM comments WHERE commentid = ?"
+	case ReportTargetUser:
+		query = "SELECT userid FROM users WHERE userid = ?"
+	default:
+		return 0, fmt.Errorf("unknown report target %q", targetType)
+	}
+
+	var ownerID int
+	err := db.c.QueryRow(query, targetID).Scan(&ownerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, sql.ErrNoRows // Content not found
+	} else if err != nil {
+		return 0, fmt.Errorf("error checking reported content: %w", err)
+	}
+	return ownerID, nil
+}
+
+// removeReportTarget deletes the reported photo or comment.
+func (db *appdbimpl) removeReportTarget(moderatorID int, report Report) error {
+	details := fmt.Sprintf("report %d", report.ReportID)
+	switch report.TargetType {
+	case ReportTargetPhoto:
+		if err := db.removePhoto(report.TargetID); err != nil {
+			return err
+		}
+		return db.logAuditEvent(moderatorID, AuditPhotoDelete, AuditTargetPhoto, report.TargetID, details)
+	case ReportTargetComment:
+		var photoID int
+		var hidden bool
+		err := db.c.QueryRow("SELECT photoid, hidden FROM comments WHERE commentid = ?", report.TargetID).Scan(&photoID, &hidden)
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows // Comment not found
+		} else if err != nil {
+			return fmt.Errorf("error checking existing comment: %w", err)
+		}
+		if err := db.removeComment(photoID, report.TargetID, hidden); err != nil {
+			return err
+		}
+		return db.logAuditEvent(moderatorID, AuditCommentDelete, AuditTargetComment, report.TargetID, details)
+	default:
+		return errors.New("only photos and comments can be removed")
+	}
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// GetStreamMarker returns how far the user has seen their stream, with the number of photos in the stream uploaded
+// since then.
+func (db *appdbimpl) GetStreamMarker(userID int) (StreamMarker, error) {
+	var marker StreamMarker
+
+	var seenAt sql.NullTime
+	err := db.c.QueryRow("SELECT streamSeenAt FROM users WHERE userid = ?", userID).Scan(&seenAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return marker, sql.ErrNoRows // User not found
+	} else if err != nil {
+		return marker, fmt.Errorf("error fetching stream marker: %w", err)
+	}
+
+	var since time.Time
+	if seenAt.Valid {
+		marker.LastSeen = &seenAt.Time
+		since = seenAt.Time
+	}
+
+	marker.NewCount, err = db.countStreamPhotos(userID, since)
+	if err != nil {
+		return marker, err
+	}
+	return marker, nil
+}
+
+// SetStreamSeen marks the stream of the user as seen up to the specified photo, which must be in their stream: other
+// photos are reported as not found, whether they exist or not. The marker never moves back, so that clients seeing the
+// stream out of order do not show photos as new again.
+func (db *appdbimpl) SetStreamSeen(userID, photoID int) (StreamMarker, error) {
+	query := `SELECT p.uploadDate FROM photos p
+		WHERE p.photoid = ? AND p.userid IN (SELECT followingid FROM following WHERE userid = ?)
+			AND ` + notBannedCondition("p.userid") + ` AND ` + notMutedCondition("p.userid") + ` AND ` + notSuspendedCondition("p.userid")
+	if db.timelineLength > 0 {
+		query = `SELECT t.uploadDate FROM timelines t
+			WHERE t.photoid = ? AND t.userid = ?
+				AND ` + notBannedCondition("t.authorid") + ` AND ` + notMutedCondition("t.authorid") + ` AND ` + notSuspendedCondition("t.authorid")
+	}
+
+	var uploadDate time.Time
+	err := db.c.QueryRow(query, photoID, userID, userID, userID, userID).Scan(&uploadDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return StreamMarker{}, sql.ErrNoRows // Photo not in the stream
+	} else if err != nil {
+		return StreamMarker{}, fmt.Errorf("error checking stream photo: %w", err)
+	}
+
+	// Only move the marker forward, in a single statement so that concurrent requests cannot move it back.
+	_, err = db.c.Exec("UPDATE users SET streamSeenAt = ? WHERE userid = ? AND (streamSeenAt IS NULL OR julianday(streamSeenAt) < julianday(?))",
+		uploadDate.UTC(), userID, uploadDate.UTC())
+	if err != nil {
+		return StreamMarker{}, fmt.Errorf("error updating stream marker: %w", err)
+	}
+
+	return db.GetStreamMarker(userID)
+}
+
+// countStreamPhotos returns the number of photos in the stream of the user uploaded after the specified time.
+func (db *appdbimpl) countStreamPhotos(userID int, since time.Time) (int, error) {
+	// Upload dates may be stored with different time zone offsets, so they are compared as UTC Julian days.
+	query := `SELECT COUNT(*) FROM photos p
+		WHERE p.userid IN (SELECT followingid FROM following WHERE userid = ?) AND julianday(p.uploadDate) > julianday(?)
+			AND ` + notBannedCondition("p.userid") + ` AND ` + notMutedCondition("p.userid") + ` AND ` + notSuspendedCondition("p.userid")
+	if db.timelineLength > 0 {
+		query = `SELECT COUNT(*) FROM timelines t
+			WHERE t.userid = ? AND julianday(t.uploadDate) > julianday(?)
+				AND ` + notBannedCondition("t.authorid") + ` AND ` + notMutedCondition("t.authorid") + ` AND ` + notSuspendedCondition("t.authorid")
+	}
+
+	var count int
+	if err := db.c.QueryRow(query, userID, since.UTC(), userID, userID, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error counting stream photos: %w", err)
+	}
+	return count, nil
+}
@@ -11,25 +11,33 @@ func (db *appdbimpl) CreateUser(u User) (User, error) {
 	usernameLower := strings.ToLower(u.Username)
 
 	// Check if the user already exists in the database.
-	err := db.c.QueryRow("SELECT userid, username FROM users WHERE LOWER(username) = ?", usernameLower).Scan(&user.UserID, &user.Username)
+	var suspended bool
+	err := db.c.QueryRow("SELECT userid, username, suspended FROM users WHERE LOWER(username) = ?", usernameLower).Scan(&user.UserID, &user.Username, &suspended)
 	if err == nil {
-		// The user already exists, return their data.
-		return user, nil
+		// The user already exists, return their data, unless their account has been suspended.
+		if suspended {
+			return User{}, ErrUserSuspended
+		}
+		return user, db.logAuditEvent(user.UserID, AuditSessionCreate, AuditTargetUser, user.UserID, "")
 	}
 
-	// If the user doesn't exist, create a new user.
-	result, err := db.c.Exec("INSERT INTO users (username) VALUES (?)", u.Username)
-	if err != nil {
-		return user, err
-	}
-
-	// Get the ID of the newly created user.
-	id, err := result.LastInsertId()
-	if err != nil {
-		return u, err
-	}
-
-	// Update the user's data.
-	u.UserID = int(id)
-	return u, nil
+	// Create the user and record the session together.
+	err = db.withTx(func(tx *appdbimpl) error {
+		// If the user doesn't exist, create a new user.
+		result, err := tx.c.Exec("INSERT INTO users (username) VALUES (?)", u.Username)
+		if err != nil {
+			return err
+		}
+
+		// Get the ID of the newly created user.
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		// Update the user's data.
+		u.UserID = int(id)
+		return tx.logAuditEvent(u.UserID, AuditSessionCreate, AuditTargetUser, u.UserID, "new user")
+	})
+	return u, err
 }
@@ -8,56 +8,255 @@ type User struct {
 	Username string `json:"username"` // User's username
 }
 
+// Roles of the users
+const (
+	RoleUser      = "user"      // Regular user
+	RoleModerator = "moderator" // Handles the reports
+	RoleAdmin     = "admin"     // Platform operator, handles the reports and manages the users
+)
+
 // Photo structure
 type Photo struct {
-	UserID        int       `json:"userID"`
-	PhotoID       int       `json:"photoID"`
-	Username      string    `json:"username"`
-	ImageData     []byte    `json:"imageData"`
-	UploadDate    time.Time `json:"uploadDate"`
-	LikesCount    int       `json:"likesCount"`
-	CommentsCount int       `json:"commentsCount"`
+	UserID          int       `json:"userID"`
+	PhotoID         int       `json:"photoID"`
+	Username        string    `json:"username"`
+	ImageData       []byte    `json:"imageData"`
+	UploadDate      time.Time `json:"uploadDate"`
+	LikesCount      int       `json:"likesCount"`
+	CommentsCount   int       `json:"commentsCount"`
+	CommentsEnabled bool      `json:"commentsEnabled"` // False when the owner turned comments off
 }
 
 // CompletePhoto represents a photo object that includes the author's username, the image URL, the number of "likes" and comments,
 // and details about users who have liked or commented, including the likes and comments themselves.
 type CompletePhoto struct {
-	UserID        int       `json:"userID"`
-	PhotoID       int       `json:"photoID"`
-	Username      string    `json:"username"`
-	ImageData     []byte    `json:"imageData"`
-	UploadDate    time.Time `json:"uploadDate"`
-	LikesCount    int       `json:"likesCount"`
-	Likes         []Like    `json:"likes"`
-	CommentsCount int       `json:"commentsCount"`
-	Comments      []Comment `json:"comments"`
+	UserID          int       `json:"userID"`
+	PhotoID         int       `json:"photoID"`
+	Username        string    `json:"username"`
+	ImageData       []byte    `json:"imageData"`
+	UploadDate      time.Time `json:"uploadDate"`
+	LikesCount      int       `json:"likesCount"`
+	Likes           []Like    `json:"likes"`
+	CommentsCount   int       `json:"commentsCount"`
+	Comments        []Comment `json:"comments"`
+	CommentsEnabled bool      `json:"commentsEnabled"`
+	LikedByViewer   bool      `json:"likedByViewer"`          // True if the user viewing the photo liked it
+	ViewerLikeID    int       `json:"viewerLikeID,omitempty"` // ID of the viewer's like, if any
 }
 
 // Like structure
 type Like struct {
-	LikeID  int `json:"likeID"`
-	UserID  int `json:"userID"`
-	PhotoID int `json:"photoID"`
+	LikeID   int    `json:"likeID"`
+	UserID   int    `json:"userID"`
+	Username string `json:"username"` // Username of the user who liked the photo
+	PhotoID  int    `json:"photoID"`
 }
 
 // Comment structure
 type Comment struct {
-	CommentID      int       `json:"commentID"`
-	AuthorID       int       `json:"authorID"`
-	AuthorUsername string    `json:"authorUsername"`
-	PhotoID        int       `json:"photoID"`
-	CommentText    string    `json:"commentText"`
-	UploadDate     time.Time `json:"uploadDate"`
+	CommentID      int        `json:"commentID"`
+	AuthorID       int        `json:"authorID"`
+	AuthorUsername string     `json:"authorUsername"`
+	PhotoID        int        `json:"photoID"`
+	CommentText    string     `json:"commentText"`
+	UploadDate     time.Time  `json:"uploadDate"`
+	EditedAt       *time.Time `json:"editedAt,omitempty"` // Last edit date, nil if never edited
+	Hidden         bool       `json:"hidden"`             // Hidden by the photo owner, pending review
+}
+
+// CommentRevision structure, holding a previous version of an edited comment
+type CommentRevision struct {
+	RevisionID  int       `json:"revisionID"`
+	CommentID   int       `json:"commentID"`
+	CommentText string    `json:"commentText"`
+	RevisedAt   time.Time `json:"revisedAt"` // When this text was replaced
+}
+
+// Ban structure, describing a user banned by another user
+type Ban struct {
+	UserID    int        `json:"userID"`              // Banned user's identifier
+	Username  string     `json:"username"`            // Banned user's username
+	Reason    string     `json:"reason,omitempty"`    // Private note, only visible to the user who banned
+	CreatedAt time.Time  `json:"createdAt"`           // Ban date
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"` // Date when the ban lifts, nil for permanent bans
 }
 
 // Profile structure that includes the number of "followers", "following" and photo uploaded, including their arrays
 type Profile struct {
-	UserID              int             `json:"userID"`              // User's identifier
-	Username            string          `json:"username"`            // User's username
-	Followers           []User          `json:"followers"`           // followers list
-	Following           []User          `json:"following"`           // following list
-	FollowersCount      int             `json:"followersCount"`      // followers number
-	FollowingCount      int             `json:"followingCount"`      // following number
-	UploadedPhotos      []CompletePhoto `json:"uploadedPhotos"`      // Photos array
-	UploadedPhotosCount int             `json:"uploadedPhotosCount"` // Uploaded photos number
+	UserID               int             `json:"userID"`                // User's identifier
+	Username             string          `json:"username"`              // User's username
+	Private              bool            `json:"private"`               // True if only approved followers can see the content
+	Followers            []User          `json:"followers"`             // followers list
+	Following            []User          `json:"following"`             // following list
+	FollowersCount       int             `json:"followersCount"`        // followers number
+	FollowingCount       int             `json:"followingCount"`        // following number
+	UploadedPhotos       []CompletePhoto `json:"uploadedPhotos"`        // Photos array
+	UploadedPhotosCount  int             `json:"uploadedPhotosCount"`   // Uploaded photos number
+	MutualFollowers      []User          `json:"mutualFollowers"`       // First users followed by the viewer who follow this user
+	MutualFollowersCount int             `json:"mutualFollowersCount"`  // Number of users followed by the viewer who follow this user
+	Email                string          `json:"email,omitempty"`       // Email address, only shown to the user
+	EmailDigest          string          `json:"emailDigest,omitempty"` // Frequency of the email digests, only shown to the user
+}
+
+// Number of mutual followers included in a profile
+const profileMutualFollowers = 3
+
+// Report structure, describing a photo, comment or user flagged as abusive, and the decision taken on it
+type Report struct {
+	ReportID   int        `json:"reportID"`
+	ReporterID int        `json:"reporterID"`
+	TargetType string     `json:"targetType"` // "photo", "comment" or "user"
+	TargetID   int        `json:"targetID"`
+	Reason     string     `json:"reason"`
+	Details    string     `json:"details,omitempty"` // Free text written by the reporter
+	Status     string     `json:"status"`            // "open", "resolved" or "dismissed"
+	CreatedAt  time.Time  `json:"createdAt"`
+	ResolverID int        `json:"resolverID,omitempty"` // Moderator or admin who took the decision
+	Action     string     `json:"action,omitempty"`     // "remove", "suspend" or "dismiss"
+	Note       string     `json:"note,omitempty"`       // Note attached to the decision
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+}
+
+// AuditEvent structure, recording a security- or moderation-relevant action
+type AuditEvent struct {
+	EventID    int       `json:"eventID"`
+	ActorID    int       `json:"actorID,omitempty"` // User who took the action, 0 for the system
+	Action     string    `json:"action"`
+	TargetType string    `json:"targetType"` // "user", "photo", "comment" or "report"
+	TargetID   int       `json:"targetID"`
+	Details    string    `json:"details,omitempty"`
+	RequestID  string    `json:"requestID,omitempty"` // ID of the request that caused the action
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// AuditFilter selects the audit events to return. Zero values match every event.
+type AuditFilter struct {
+	ActorID    int
+	Action     string
+	TargetType string
+	TargetID   int
+	Since      time.Time // Only events recorded at or after this time
+	Until      time.Time // Only events recorded before this time
+	Limit      int
+	Offset     int
+}
+
+// Suggestion structure, describing a user the viewer may want to follow
+type Suggestion struct {
+	UserID          int    `json:"userID"`
+	Username        string `json:"username"`
+	Reason          string `json:"reason"`          // Why the user is suggested, e.g. "followed by alice and 3 others"
+	FollowedByCount int    `json:"followedByCount"` // Number of users followed by the viewer who follow this user
+	FollowsViewer   bool   `json:"followsViewer"`   // True if this user follows the viewer
+}
+
+// Relationship structure, describing how the viewer and another user are related
+type Relationship struct {
+	UserID                int  `json:"userID"`                // The other user's identifier
+	Following             bool `json:"following"`             // The viewer follows the other user
+	FollowedBy            bool `json:"followedBy"`            // The other user follows the viewer
+	Banned                bool `json:"banned"`                // The viewer banned the other user
+	BannedBy              bool `json:"bannedBy"`              // The other user banned the viewer
+	Muted                 bool `json:"muted"`                 // The viewer muted the other user
+	FollowRequested       bool `json:"followRequested"`       // The viewer asked to follow the other user
+	FollowRequestReceived bool `json:"followRequestReceived"` // The other user asked to follow the viewer
+}
+
+// Notification structure. Likes and comments on the same photo are aggregated into a single notification, whose
+// actor is the most recent one.
+type Notification struct {
+	NotificationID int       `json:"notificationID"` // ID of the most recent notification of the group
+	Type           string    `json:"type"`           // "follow", "follow-request", "like", "comment" or "mention"
+	ActorID        int       `json:"actorID"`
+	ActorUsername  string    `json:"actorUsername"`
+	ActorsCount    int       `json:"actorsCount"` // Number of distinct users in the group
+	PhotoID        int       `json:"photoID,omitempty"`
+	CommentID      int       `json:"commentID,omitempty"`
+	Message        string    `json:"message"` // E.g. "alice and 4 others liked your photo"
+	Read           bool      `json:"read"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// Webhook structure, describing a subscription of an external service to the events of the platform
+type Webhook struct {
+	WebhookID int       `json:"webhookID"`
+	OwnerID   int       `json:"ownerID"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"` // Key of the HMAC signature of the payloads
+	Events    []string  `json:"events"`           // Events delivered to the webhook
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WebhookDelivery structure, describing a payload sent, or to be sent, to a webhook
+type WebhookDelivery struct {
+	DeliveryID     int        `json:"deliveryID"`
+	WebhookID      int        `json:"webhookID"`
+	Event          string     `json:"event"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"` // "pending", "delivered" or "dead"
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"nextAttemptAt"`
+	ResponseStatus int        `json:"responseStatus,omitempty"` // HTTP status of the last attempt
+	LastError      string     `json:"lastError,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+	URL            string     `json:"-"` // URL and secret of the webhook, only set by GetDueWebhookDeliveries
+	Secret         string     `json:"-"`
+}
+
+// Digest structure, summarizing the unread notifications of a user for an email digest
+type Digest struct {
+	UserID    int            `json:"userID"`
+	Username  string         `json:"username"`
+	Email     string         `json:"email"`
+	Frequency string         `json:"frequency"` // "daily" or "weekly"
+	Since     time.Time      `json:"since"`     // Start of the period covered by the digest
+	Counts    map[string]int `json:"counts"`    // Unread notifications received in the period, by type
+}
+
+// NotificationPreferences structure, describing which events notify a user and through which channels
+type NotificationPreferences struct {
+	Events      map[string]NotificationChannels `json:"events"`                // Channels of each kind of event: "likes", "comments", "follows" or "mentions"
+	PausedUntil *time.Time                      `json:"pausedUntil,omitempty"` // No notifications are sent until this time
+}
+
+// NotificationChannels structure, describing the channels through which an event notifies a user
+type NotificationChannels struct {
+	InApp bool `json:"inApp"` // Listed in the notifications
+	Push  bool `json:"push"`  // Pushed in real time to the connected clients
+	Email bool `json:"email"` // Summarized in the email digests
+}
+
+// FeedWeights structure, describing how the photos of the ranked stream are scored
+type FeedWeights struct {
+	Recency         float64       `json:"recency"`         // Weight of the freshness of the photo, halved every RecencyHalfLife
+	RecencyHalfLife time.Duration `json:"recencyHalfLife"` // Age at which the freshness of a photo is halved
+	Likes           float64       `json:"likes"`           // Weight of the likes per hour since the upload
+	Comments        float64       `json:"comments"`        // Weight of the comments per hour since the upload
+	Affinity        float64       `json:"affinity"`        // Weight of the viewer's past likes and comments on the author's photos
+	Popular         float64       `json:"popular"`         // Factor applied to the photos of users not followed; zero leaves them out
+	CandidateWindow time.Duration `json:"candidateWindow"` // Only the photos uploaded in this window are ranked
+	MaxCandidates   int           `json:"maxCandidates"`   // Maximum number of photos ranked, most recent first
+}
+
+// TrendingPhoto structure, describing a photo of the explore page with its trending score
+type TrendingPhoto struct {
+	PhotoID int     `json:"photoID"`
+	UserID  int     `json:"userID"`
+	Score   float64 `json:"score"` // Likes and comments per hour in the window, normalized by the author's followers
+}
+
+// StreamMarker structure, describing how far the user has seen their stream
+type StreamMarker struct {
+	LastSeen *time.Time `json:"lastSeen"` // Upload date of the most recent photo seen, nil if the stream was never seen
+	NewCount int        `json:"newCount"` // Photos in the stream uploaded after LastSeen
+}
+
+// Collection structure, describing a named set of photos privately saved by a user
+type Collection struct {
+	CollectionID int       `json:"collectionID"`
+	OwnerID      int       `json:"ownerID"`
+	Name         string    `json:"name"`
+	PhotosCount  int       `json:"photosCount"` // Photos of the collection visible to the owner
+	CreatedAt    time.Time `json:"createdAt"`
 }
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Weights of the signals used to rank the follow suggestions.
+const (
+	suggestionFollowedByWeight     = 4 // For each user followed by the viewer who follows the candidate
+	suggestionFollowsViewerWeight  = 3 // If the candidate follows the viewer
+	suggestionSharedFollowerWeight = 2 // For each follower of the viewer who also follows the candidate
+	suggestionRecentPhotoWeight    = 1 // For each photo uploaded in the activity window, up to suggestionMaxRecentPhotos
+	suggestionMaxRecentPhotos      = 5
+
+	suggestionActivityWindow = 7 * 24 * time.Hour
+)
+
+// GetSuggestions returns a page of the users the specified user may want to follow, best matches first.
+// Candidates are ranked by how many of the viewer's followings follow them, whether they follow the viewer, how many
+// followers they share with the viewer and how many photos they uploaded recently. Users already followed or
+// requested, banned in either direction, suspended, or without any signal are left out.
+func (db *appdbimpl) GetSuggestions(userID, limit, offset int) ([]Suggestion, error) {
+	var suggestions []Suggestion
+
+	rows, err := db.c.Query(`WITH following AS (SELECT userid FROM followers WHERE followerid = ? AND `+notSuspendedCondition("userid")+`),
+		myfollowers AS (SELECT followerid FROM followers WHERE userid = ? AND `+notSuspendedCondition("followerid")+`),
+		candidates AS (
+			SELECT u.userid, u.username,
+				(SELECT COUNT(*) FROM followers f WHERE f.userid = u.userid AND f.followerid IN following) AS followedBy,
+				(SELECT MIN(fu.username) FROM followers f JOIN users fu ON f.followerid = fu.userid
+					WHERE f.userid = u.userid AND f.followerid IN following) AS firstFollowedBy,
+				EXISTS (SELECT 1 FROM myfollowers WHERE followerid = u.userid) AS followsViewer,
+				(SELECT COUNT(*) FROM followers f WHERE f.userid = u.userid AND f.followerid IN myfollowers) AS sharedFollowers,
+				(SELECT COUNT(*) FROM photos p WHERE p.userid = u.userid AND p.uploadDate >= ?) AS recentPhotos
+			FROM users u
+			WHERE u.userid <> ?
+				AND u.userid NOT IN (SELECT userid FROM followers WHERE followerid = ?)
+				AND u.userid NOT IN (SELECT userid FROM follow_requests WHERE requesterid = ?)
+				AND `+notBannedCondition("u.userid")+` AND `+notSuspendedCondition("u.userid")+`)
+		SELECT userid, username, followedBy, firstFollowedBy, followsViewer, sharedFollowers, recentPhotos,
+			followedBy * ? + followsViewer * ? + sharedFollowers * ? + MIN(recentPhotos, ?) * ? AS score
+		FROM candidates WHERE score > 0
+		ORDER BY score DESC, userid LIMIT ? OFFSET ?`,
+		userID, userID, time.Now().Add(-suggestionActivityWindow), userID, userID, userID, userID, userID,
+		suggestionFollowedByWeight, suggestionFollowsViewerWeight, suggestionSharedFollowerWeight,
+		suggestionMaxRecentPhotos, suggestionRecentPhotoWeight, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching suggestions: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	// Iterate over the rows to extract each suggestion's data.
+	for rows.Next() {
+		var s Suggestion
+		var firstFollowedBy sql.NullString
+		var sharedFollowers, recentPhotos, score int
+		if err := rows.Scan(&s.UserID, &s.Username, &s.FollowedByCount, &firstFollowedBy, &s.FollowsViewer,
+			&sharedFollowers, &recentPhotos, &score); err != nil {
+			return nil, fmt.Errorf("error scanning suggestion row: %w", err)
+		}
+		s.Reason = suggestionReason(s, firstFollowedBy.String, sharedFollowers)
+		suggestions = append(suggestions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over suggestion rows: %w", err)
+	}
+
+	return suggestions, nil
+}
+
+// suggestionReason describes the strongest signal behind a suggestion.
+func suggestionReason(s Suggestion, firstFollowedBy string, sharedFollowers int) string {
+	switch {
+	case s.FollowedByCount == 1:
+		return "followed by " + firstFollowedBy
+	case s.FollowedByCount == 2:
+		return "followed by " + firstFollowedBy + " and 1 other"
+	case s.FollowedByCount > 2:
+		return fmt.Sprintf("followed by %s and %d others", firstFollowedBy, s.FollowedByCount-1)
+	case s.FollowsViewer:
+		return "follows you"
+	case sharedFollowers == 1:
+		return "followed by 1 of your followers"
+	case sharedFollowers > 1:
+		return fmt.Sprintf("followed by %d of your followers", sharedFollowers)
+	default:
+		return "recently active"
+	}
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// UseTimelines switches the stream to fan-out-on-write: each new photo is copied to the timelines of the followers of
+// its author, keeping the most recent `length` photos of each timeline, and GetMyStream reads the timeline instead of
+// joining follows and photos. The timelines are rebuilt from the follows, as they are not kept up to date while
+// disabled. It must be called before serving requests.
+func (db *appdbimpl) UseTimelines(length int) error {
+	if length <= 0 {
+		return fmt.Errorf("invalid timeline length %d", length)
+	}
+
+	_, err := db.c.Exec("DELETE FROM timelines")
+	if err != nil {
+		return fmt.Errorf("error removing timelines: %w", err)
+	}
+
+	_, err = db.c.Exec(`INSERT INTO timelines (userid, photoid, authorid, uploadDate)
+		SELECT userid, photoid, authorid, uploadDate FROM (
+			SELECT f.followerid AS userid, p.photoid, p.userid AS authorid, p.uploadDate,
+				ROW_NUMBER() OVER (PARTITION BY f.followerid ORDER BY julianday(p.uploadDate) DESC, p.photoid DESC) AS position
+			FROM followers f JOIN photos p ON p.userid = f.userid)
+		WHERE position <= ?`, length)
+	if err != nil {
+		return fmt.Errorf("error building timelines: %w", err)
+	}
+
+	db.timelineLength = length
+	return nil
+}
+
+// fanOutPhoto adds a new photo to the timelines of the followers of its author.
+func (db *appdbimpl) fanOutPhoto(p Photo) error {
+	if db.timelineLength == 0 {
+		return nil
+	}
+
+	_, err := db.c.Exec(`INSERT OR IGNORE INTO timelines (userid, photoid, authorid, uploadDate)
+		SELECT followerid, ?, ?, ? FROM followers WHERE userid = ?`, p.PhotoID, p.UserID, p.UploadDate, p.UserID)
+	if err != nil {
+		return fmt.Errorf("error adding photo to timelines: %w", err)
+	}
+
+	return db.trimTimelines("userid IN (SELECT followerid FROM followers WHERE userid = ?)", p.UserID)
+}
+
+// backfillTimeline adds the most recent photos of a newly followed user to the timeline of the follower.
+func (db *appdbimpl) backfillTimeline(userID, authorID int) error {
+	if db.timelineLength == 0 {
+		return nil
+	}
+
+	_, err := db.c.Exec(`INSERT OR IGNORE INTO timelines (userid, photoid, authorid, uploadDate)
+		SELECT ?, photoid, userid, uploadDate FROM photos WHERE userid = ? ORDER BY julianday(uploadDate) DESC LIMIT ?`,
+		userID, authorID, db.timelineLength)
+	if err != nil {
+		return fmt.Errorf("error backfilling timeline: %w", err)
+	}
+
+	return db.trimTimelines("userid = ?", userID)
+}
+
+// trimTimelines removes the oldest photos of the timelines matching the condition, beyond the timeline length.
+// Upload dates may be stored with different time zone offsets, so the timelines are sorted by UTC Julian days.
+func (db *appdbimpl) trimTimelines(condition string, args ...interface{}) error {
+	args = append(args, db.timelineLength)
+	_, err := db.c.Exec(`DELETE FROM timelines WHERE `+condition+`
+		AND photoid NOT IN (SELECT t.photoid FROM timelines t WHERE t.userid = timelines.userid
+			ORDER BY julianday(t.uploadDate) DESC, t.photoid DESC LIMIT ?)`, args...)
+	if err != nil {
+		return fmt.Errorf("error trimming timelines: %w", err)
+	}
+	return nil
+}
+
+// removeFromTimeline removes the photos of the author from the timeline of the user.
+func (db *appdbimpl) removeFromTimeline(userID, authorID int) error {
+	_, err := db.c.Exec("DELETE FROM timelines WHERE userid = ? AND authorid = ?", userID, authorID)
+	if err != nil {
+		return fmt.Errorf("error removing photos from timeline: %w", err)
+	}
+	return nil
+}
+
+// getTimeline returns the photos in the timeline of the user uploaded after `since`, in reverse chronological order.
+// Photos of users who are banned, muted or suspended are left out, as in the read-time stream.
+func (db *appdbimpl) getTimeline(userID int, since time.Time) ([]CompletePhoto, error) {
+	var stream []CompletePhoto
+
+	rows, err := db.c.Query(`SELECT p.photoid, p.userid, p.username, p.imageData, p.uploadDate, p.likesCount, p.commentsCount, p.commentsEnabled
+		FROM timelines t JOIN photos p ON t.photoid = p.photoid
+		WHERE t.userid = ? AND julianday(t.uploadDate) > julianday(?)
+			AND `+notBannedCondition("t.authorid")+` AND `+notMutedCondition("t.authorid")+` AND `+notSuspendedCondition("t.authorid")+`
+		ORDER BY julianday(t.uploadDate) DESC, t.photoid DESC`, userID, since.UTC(), userID, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching timeline: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	for rows.Next() {
+		var photo CompletePhoto
+		if err := rows.Scan(&photo.PhotoID, &photo.UserID, &photo.Username, &photo.ImageData, &photo.UploadDate, &photo.LikesCount, &photo.CommentsCount, &photo.CommentsEnabled); err != nil {
+			return nil, fmt.Errorf("error scanning timeline row: %w", err)
+		}
+		stream = append(stream, photo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over timeline rows: %w", err)
+	}
+
+	// Retrieve the likes and comments of each photo, once the rows are closed.
+	for i := range stream {
+		if err := db.getPhotoDetails(userID, &stream[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return stream, nil
+}
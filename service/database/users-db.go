@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 )
 
 // UpdateUsername updates the username of the specified user in the database.
@@ -25,23 +26,33 @@ func (db *appdbimpl) UpdateUsername(userID int, newUsername string) error {
 		return fmt.Errorf("username %s already in use by another user", newUsername)
 	}
 
-	// Update the username in the database
-	_, err = db.c.Exec("UPDATE users SET username = ? WHERE userid = ?", newUsername, userID)
+	// Keep the previous username for the audit log.
+	var oldUsername string
+	err = db.c.QueryRow("SELECT username FROM users WHERE userid = ?", userID).Scan(&oldUsername)
 	if err != nil {
-		return fmt.Errorf("error updating username in database: %w", err)
+		return fmt.Errorf("error fetching current username: %w", err)
 	}
 
-	_, err = db.c.Exec("UPDATE photos SET username = ? WHERE userid = ?", newUsername, userID)
-	if err != nil {
-		return fmt.Errorf("error updating username in database: %w", err)
-	}
+	// Update the username everywhere and record the change together.
+	return db.withTx(func(tx *appdbimpl) error {
+		// Update the username in the database
+		_, err := tx.c.Exec("UPDATE users SET username = ? WHERE userid = ?", newUsername, userID)
+		if err != nil {
+			return fmt.Errorf("error updating username in database: %w", err)
+		}
 
-	_, err = db.c.Exec("UPDATE comments SET username = ? WHERE userid = ?", newUsername, userID)
-	if err != nil {
-		return fmt.Errorf("error updating username in database: %w", err)
-	}
+		_, err = tx.c.Exec("UPDATE photos SET username = ? WHERE userid = ?", newUsername, userID)
+		if err != nil {
+			return fmt.Errorf("error updating username in database: %w", err)
+		}
 
-	return nil
+		_, err = tx.c.Exec("UPDATE comments SET username = ? WHERE userid = ?", newUsername, userID)
+		if err != nil {
+			return fmt.Errorf("error updating username in database: %w", err)
+		}
+
+		return tx.logAuditEvent(userID, AuditUsernameChange, AuditTargetUser, userID, oldUsername+" -> "+newUsername)
+	})
 }
 
 // GetUserProfile retrieves the details of the specified user's profile,
@@ -51,20 +62,26 @@ func (db *appdbimpl) GetUserProfile(requestingUserID, requestedUserID int) (Prof
 	var profile Profile
 
 	// Check if the user to be searched exists.
-	var existingUser int
-	err := db.c.QueryRow("SELECT 1 FROM users WHERE userid = ?", requestedUserID).Scan(&existingUser)
+	var private bool
+	err := db.c.QueryRow("SELECT private FROM users WHERE userid = ?", requestedUserID).Scan(&private)
 	if errors.Is(err, sql.ErrNoRows) {
 		return profile, fmt.Errorf("the user you are searching doesn't exist")
 	} else if err != nil {
 		return profile, fmt.Errorf("error checking existing user: %w", err)
 	}
 
-	// Check if the user is banned by the user being searched.
-	var isBanned int
-	err = db.c.QueryRow("SELECT 1 FROM banned_users WHERE userid = ? AND banneduserid = ?", requestedUserID, requestingUserID).Scan(&isBanned)
-	if err == nil {
-		// The user is banned by the searched user.
-		return profile, errors.New("you are banned by this user")
+	// The profiles of suspended users are hidden.
+	if err := db.checkSuspended(requestedUserID); errors.Is(err, ErrUserSuspended) {
+		return profile, sql.ErrNoRows
+	} else if err != nil {
+		return profile, err
+	}
+
+	// Users banned by the searched user cannot see their profile. Users who banned the searched user
+	// only see the basic details of the profile, so that they can still lift the ban.
+	banErr := db.checkBan(requestingUserID, requestedUserID)
+	if banErr != nil && !errors.Is(banErr, ErrUserBanned) {
+		return profile, banErr
 	}
 
 	// Retrieve user details (userid, username)
@@ -73,6 +90,12 @@ func (db *appdbimpl) GetUserProfile(requestingUserID, requestedUserID int) (Prof
 		return profile, err
 	}
 
+	// Users who are not approved followers of a private account only see the basic details of the profile.
+	privacyErr := db.checkPrivacy(requestingUserID, requestedUserID)
+	if privacyErr != nil && !errors.Is(privacyErr, ErrPrivateAccount) {
+		return profile, privacyErr
+	}
+
 	// Retrieve the list of followers.
 	followers, err := db.GetFollowers(requestedUserID)
 	if err != nil {
@@ -85,52 +108,115 @@ func (db *appdbimpl) GetUserProfile(requestingUserID, requestedUserID int) (Prof
 		return profile, err
 	}
 
-	// Retrieve the list of photos uploaded by the user.
-	uploadedPhotos, err := db.GetUploadedPhotos(requestedUserID)
-	if err != nil {
-		return profile, err
+	// Retrieve the list of photos uploaded by the user. Viewers who only see the basic details of the profile only get
+	// the number of photos.
+	restricted := banErr != nil || privacyErr != nil
+	var uploadedPhotos []CompletePhoto
+	var uploadedPhotosCount int
+	if restricted {
+		err = db.c.QueryRow("SELECT COUNT(*) FROM photos WHERE userid = ?", requestedUserID).Scan(&uploadedPhotosCount)
+		if err != nil {
+			return profile, fmt.Errorf("error counting uploaded photos: %w", err)
+		}
+	} else {
+		uploadedPhotos, err = db.GetUploadedPhotos(requestingUserID, requestedUserID)
+		if err != nil {
+			return profile, err
+		}
+		uploadedPhotosCount = len(uploadedPhotos)
 	}
 
 	// Construct the user's profile with the gathered data.
 	profile = Profile{
 		UserID:              user.UserID,
 		Username:            user.Username,
+		Private:             private,
 		Followers:           followers,
 		Following:           following,
 		FollowersCount:      len(followers),
 		FollowingCount:      len(following),
 		UploadedPhotos:      uploadedPhotos,
-		UploadedPhotosCount: len(uploadedPhotos),
+		UploadedPhotosCount: uploadedPhotosCount,
+	}
+
+	// The email settings are private.
+	if requestingUserID == requestedUserID {
+		err = db.c.QueryRow("SELECT email, emailDigest FROM users WHERE userid = ?", requestedUserID).Scan(&profile.Email, &profile.EmailDigest)
+		if err != nil {
+			return profile, fmt.Errorf("error fetching email settings: %w", err)
+		}
 	}
-	return profile, err
+
+	// The users followed by the requesting user who also follow the searched user are shown even for private accounts,
+	// since the requesting user can already see whom they follow.
+	if banErr == nil && requestingUserID != requestedUserID {
+		profile.MutualFollowers, profile.MutualFollowersCount, err = db.getMutualFollowers(requestingUserID, requestedUserID, profileMutualFollowers, 0)
+		if err != nil {
+			return profile, err
+		}
+	}
+
+	if restricted {
+		profile.Followers = nil
+		profile.Following = nil
+		return profile, nil
+	}
+
+	// Hide the followers and following users who banned, or were banned by, the requesting user.
+	banned, err := db.getBannedRelations(requestingUserID)
+	if err != nil {
+		return profile, err
+	}
+	profile.Followers = excludeUsers(profile.Followers, banned)
+	profile.Following = excludeUsers(profile.Following, banned)
+
+	return profile, nil
+}
+
+// GetMutualFollowers returns a page of the users followed by the viewer who also follow the specified user, and their
+// total number. sql.ErrNoRows is returned if the user does not exist or is suspended, and ErrBannedByUser if they
+// banned the viewer. If the viewer banned the user, the list is empty.
+func (db *appdbimpl) GetMutualFollowers(viewerID, userID, limit, offset int) ([]User, int, error) {
+	// Check if the user exists and is not suspended.
+	if err := db.checkSuspended(userID); errors.Is(err, ErrUserSuspended) || errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, sql.ErrNoRows
+	} else if err != nil {
+		return nil, 0, err
+	}
+
+	banErr := db.checkBan(viewerID, userID)
+	if errors.Is(banErr, ErrUserBanned) {
+		return nil, 0, nil
+	} else if banErr != nil {
+		return nil, 0, banErr
+	}
+
+	return db.getMutualFollowers(viewerID, userID, limit, offset)
 }
 
 // FollowUser adds a user to the specified user's following list.
-func (db *appdbimpl) FollowUser(userID, userIDToFollow int) error {
+// If the user to be followed has a private account, a follow request is sent instead, and true is returned.
+func (db *appdbimpl) FollowUser(userID, userIDToFollow int) (bool, error) {
 
 	// Check if the user being followed exists.
-	var existingUser int
-	err := db.c.QueryRow("SELECT 1 FROM users WHERE userid = ?", userIDToFollow).Scan(&existingUser)
+	var private bool
+	err := db.c.QueryRow("SELECT private FROM users WHERE userid = ?", userIDToFollow).Scan(&private)
 	if errors.Is(err, sql.ErrNoRows) {
-		return fmt.Errorf("the user you want to follow doesn't exists")
+		return false, fmt.Errorf("the user you want to follow doesn't exists: %w", sql.ErrNoRows)
 	} else if err != nil {
-		return fmt.Errorf("error checking existing user: %w", err)
+		return false, fmt.Errorf("error checking existing user: %w", err)
 	}
 
-	// Check if the user is banned by the user they are trying to follow.
-	var isBanned int
-	err = db.c.QueryRow("SELECT 1 FROM banned_users WHERE userid = ? AND banneduserid = ?", userIDToFollow, userID).Scan(&isBanned)
-	if err == nil {
-		// The user is banned by the other user.
-		return errors.New("you are banned by this user")
+	// Suspended users are reported as not found.
+	if err := db.checkSuspended(userIDToFollow); errors.Is(err, ErrUserSuspended) {
+		return false, fmt.Errorf("the user you want to follow doesn't exists: %w", sql.ErrNoRows)
+	} else if err != nil {
+		return false, err
 	}
 
-	// Check if the user has banned the other user.
-	var hasBanned int
-	err = db.c.QueryRow("SELECT 1 FROM banned_users WHERE userid = ? AND banneduserid = ?", userID, userIDToFollow).Scan(&hasBanned)
-	if err == nil {
-		// The user has banned the other user.
-		return errors.New("you have banned this user")
+	// Check if there is a ban between the two users.
+	if err := db.checkBan(userID, userIDToFollow); err != nil {
+		return false, err
 	}
 
 	// Check if the user already follows the other user.
@@ -138,36 +224,77 @@ func (db *appdbimpl) FollowUser(userID, userIDToFollow int) error {
 	err = db.c.QueryRow("SELECT 1 FROM followers WHERE userid = ? AND followerid = ?", userIDToFollow, userID).Scan(&existingFollower)
 	if err == nil {
 		// The user already follows the other user.
-		return errors.New("already followed")
+		return false, errors.New("already followed")
 	}
 
 	// Check if the user is trying to follow themselves.
 	if userID == userIDToFollow {
-		return errors.New("cannot follow yourself")
+		return false, errors.New("cannot follow yourself")
+	}
+
+	// Private accounts must approve their followers.
+	if private {
+		var existingRequest int
+		err = db.c.QueryRow("SELECT 1 FROM follow_requests WHERE userid = ? AND requesterid = ?", userIDToFollow, userID).Scan(&existingRequest)
+		if err == nil {
+			return false, errors.New("follow request already sent")
+		}
+
+		// Store the request and notify the user together.
+		return true, db.withTx(func(tx *appdbimpl) error {
+			_, err := tx.c.Exec("INSERT INTO follow_requests (userid, requesterid, createdAt) VALUES (?, ?, ?)", userIDToFollow, userID, time.Now())
+			if err != nil {
+				return fmt.Errorf("error updating follow_requests table: %w", err)
+			}
+			return tx.notify(userIDToFollow, userID, NotificationFollowRequest, 0, 0)
+		})
 	}
 
-	// Update the followers table by adding userID as a follower of userIDToFollow.
-	_, err = db.c.Exec("INSERT INTO followers (userID, followerID) VALUES (?, ?)", userIDToFollow, userID)
+	// Store the follow and notify the user together.
+	return false, db.withTx(func(tx *appdbimpl) error {
+		if err := tx.addFollower(userIDToFollow, userID); err != nil {
+			return err
+		}
+		return tx.notify(userIDToFollow, userID, NotificationFollow, 0, 0)
+	})
+}
+
+// addFollower records followerID as a follower of userID.
+func (db *appdbimpl) addFollower(userID, followerID int) error {
+	// Update the followers table by adding followerID as a follower of userID.
+	_, err := db.c.Exec("INSERT INTO followers (userID, followerID) VALUES (?, ?)", userID, followerID)
 	if err != nil {
 		return fmt.Errorf("error updating followers table: %w", err)
 	}
 
-	// Update the following table by adding userIDToFollow as a following of userID.
-	_, err = db.c.Exec("INSERT INTO following (userID, followingID) VALUES (?, ?)", userID, userIDToFollow)
+	// Update the following table by adding userID as a following of followerID.
+	_, err = db.c.Exec("INSERT INTO following (userID, followingID) VALUES (?, ?)", followerID, userID)
 	if err != nil {
 		return fmt.Errorf("error updating following table: %w", err)
 	}
 
-	return nil
+	// Add the recent photos of userID to the timeline of followerID.
+	if err := db.backfillTimeline(followerID, userID); err != nil {
+		return err
+	}
+
+	return db.enqueueWebhookEvent(WebhookEventFollowerCreated, userID, struct {
+		UserID     int `json:"userID"`
+		FollowerID int `json:"followerID"`
+	}{userID, followerID})
 }
 
 // UnfollowUser removes a user from the specified user's following list.
 func (db *appdbimpl) UnfollowUser(userID, followingID int) error {
 
 	// Check if the user is attempting to unfollow someone they are not currently following.
+	// Unfollowing a private account that did not approve the follow request yet withdraws the request.
 	var existingFollower int
 	err := db.c.QueryRow("SELECT 1 FROM followers WHERE userid = ? AND followerid = ?", followingID, userID).Scan(&existingFollower)
 	if errors.Is(err, sql.ErrNoRows) {
+		if err := db.RejectFollowRequest(followingID, userID); err == nil {
+			return nil
+		}
 		return fmt.Errorf("you are trying to unfollow someone you don't follow")
 	} else if err != nil {
 		return fmt.Errorf("error checking existing follower: %w", err)
@@ -185,11 +312,13 @@ func (db *appdbimpl) UnfollowUser(userID, followingID int) error {
 		return fmt.Errorf("error removing following: %w", err)
 	}
 
-	return nil
+	// Remove the photos of the unfollowed user from the timeline.
+	return db.removeFromTimeline(userID, followingID)
 }
 
 // BanUser adds a user to the specified user's banned list.
-func (db *appdbimpl) BanUser(userID, bannedUserID int) error {
+// The ban carries an optional private reason, and an optional expiration date after which it lifts automatically.
+func (db *appdbimpl) BanUser(userID, bannedUserID int, b Ban) error {
 
 	// Verify if the user to be banned exists.
 	var existingUser int
@@ -205,93 +334,132 @@ func (db *appdbimpl) BanUser(userID, bannedUserID int) error {
 		return errors.New("cannot ban yourself")
 	}
 
-	// Check if the user is banned by the user they are trying to ban.
-	var isBanned int
-	err = db.c.QueryRow("SELECT 1 FROM banned_users WHERE userid = ? AND banneduserid = ?", bannedUserID, userID).Scan(&isBanned)
-	if err == nil {
-		// The user is banned by the other user.
-		return errors.New("you are banned by this user")
+	// Check if there is already a ban between the two users.
+	if err := db.checkBan(userID, bannedUserID); err != nil {
+		return err
 	}
 
-	// Check if the user has already banned the other user.
-	var hasBanned int
-	err = db.c.QueryRow("SELECT 1 FROM banned_users WHERE userid = ? AND banneduserid = ?", userID, bannedUserID).Scan(&hasBanned)
-	if err == nil {
-		// User already banned.
-		return errors.New("you have banned this user")
-	}
+	// Remove the follows between the two users, store the ban and record it together.
+	return db.withTx(func(tx *appdbimpl) error {
+		// Handle the removal from followers and following if necessary.
+		// The banned user will automatically stop being a follower and following of the user who bans them,
+		// which also removes the photos of each user from the timeline of the other.
+		var follows int
+		err := tx.c.QueryRow("SELECT 1 FROM followers WHERE userid = ? AND followerid = ?", userID, bannedUserID).Scan(&follows)
+		if err == nil {
+			// Remove the banned user from followers if they are following the user executing the ban.
+			if err := tx.UnfollowUser(bannedUserID, userID); err != nil {
+				return fmt.Errorf("error handling unfollow during ban operation: %w", err)
+			}
+		}
 
-	// Handle the removal from followers and following if necessary.
-	// The banned user will automatically stop being a follower and following of the user who bans them.
-	var follows int
-	err = db.c.QueryRow("SELECT 1 FROM followers WHERE userid = ? AND followerid = ?", userID, bannedUserID).Scan(&follows)
-	if err == nil {
-		// Remove the banned user from followers if they are following the user executing the ban.
-		if err := db.UnfollowUser(bannedUserID, userID); err != nil {
-			return fmt.Errorf("error handling unfollow during ban operation: %w", err)
+		// Check if the user is following the user to be banned.
+		var followed int
+		err = tx.c.QueryRow("SELECT 1 FROM following WHERE userid = ? AND followingid = ?", userID, bannedUserID).Scan(&followed)
+		if err == nil {
+			// Remove the initiating user from following the user to be banned.
+			if err := tx.UnfollowUser(userID, bannedUserID); err != nil {
+				return fmt.Errorf("error handling unfollow during ban operation: %w", err)
+			}
 		}
-	}
 
-	// Check if the user is following the user to be banned.
-	var followed int
-	err = db.c.QueryRow("SELECT 1 FROM following WHERE userid = ? AND followingid = ?", userID, bannedUserID).Scan(&followed)
-	if err == nil {
-		// Remove the initiating user from following the user to be banned.
-		if err := db.UnfollowUser(userID, bannedUserID); err != nil {
-			return fmt.Errorf("error handling unfollow during ban operation: %w", err)
+		// Drop the pending follow requests between the two users.
+		_, err = tx.c.Exec("DELETE FROM follow_requests WHERE (userid = ? AND requesterid = ?) OR (userid = ? AND requesterid = ?)",
+			userID, bannedUserID, bannedUserID, userID)
+		if err != nil {
+			return fmt.Errorf("error removing follow requests during ban operation: %w", err)
 		}
-	}
 
-	// Update the banned_users table.
-	_, err = db.c.Exec("INSERT INTO banned_users (userid, banneduserid) VALUES (?, ?)", userID, bannedUserID)
-	if err != nil {
-		return fmt.Errorf("error updating banned_users table: %w", err)
-	}
+		// Store expiration dates in UTC, as expected by the active_bans view.
+		var expiresAt sql.NullTime
+		if b.ExpiresAt != nil {
+			expiresAt = sql.NullTime{Time: b.ExpiresAt.UTC(), Valid: true}
+		}
 
-	return nil
+		// Update the banned_users table, replacing any expired ban between the two users.
+		_, err = tx.c.Exec("INSERT OR REPLACE INTO banned_users (userid, banneduserid, createdAt, reason, expiresAt) VALUES (?, ?, ?, ?, ?)",
+			userID, bannedUserID, b.CreatedAt, b.Reason, expiresAt)
+		if err != nil {
+			return fmt.Errorf("error updating banned_users table: %w", err)
+		}
+
+		// The reason of the ban is private, so it is not recorded in the audit log.
+		details := ""
+		if expiresAt.Valid {
+			details = "until " + expiresAt.Time.Format(time.RFC3339)
+		}
+		return tx.logAuditEvent(userID, AuditBan, AuditTargetUser, bannedUserID, details)
+	})
 }
 
 // UnbanUser removes a user from the specified user's banned list.
 func (db *appdbimpl) UnbanUser(userID, bannedUserID int) error {
 	// Check if the user is trying to unban someone who is not currently banned or does not exist.
 	var existingUser int
-	err := db.c.QueryRow("SELECT 1 FROM banned_users WHERE userid = ? AND banneduserid = ?", userID, bannedUserID).Scan(&existingUser)
+	err := db.c.QueryRow("SELECT 1 FROM active_bans WHERE userid = ? AND banneduserid = ?", userID, bannedUserID).Scan(&existingUser)
 	if errors.Is(err, sql.ErrNoRows) {
 		return fmt.Errorf("you are trying to unban someone who was not banned or doesn't exists")
 	} else if err != nil {
 		return fmt.Errorf("error checking existing ban: %w", err)
 	}
 
-	// Remove the user from the banned_users table.
-	_, err = db.c.Exec("DELETE FROM banned_users WHERE userid = ? AND banneduserid = ?", userID, bannedUserID)
-	if err != nil {
-		return fmt.Errorf("error removing ban: %w", err)
-	}
+	// Remove the ban and record it together.
+	return db.withTx(func(tx *appdbimpl) error {
+		// Remove the user from the banned_users table.
+		_, err := tx.c.Exec("DELETE FROM banned_users WHERE userid = ? AND banneduserid = ?", userID, bannedUserID)
+		if err != nil {
+			return fmt.Errorf("error removing ban: %w", err)
+		}
 
-	return nil
+		return tx.logAuditEvent(userID, AuditUnban, AuditTargetUser, bannedUserID, "")
+	})
 }
 
 // GetMyStream returns the stream of the user, consisting of photos posted by their following,
 // including details such as the date-time they were posted, the number of likes, and comments.
-func (db *appdbimpl) GetMyStream(userID int) ([]CompletePhoto, error) {
+// Only the photos uploaded after `since` are returned, unless it is the zero time.
+// With fan-out-on-write, the stream is read from the precomputed timeline of the user.
+func (db *appdbimpl) GetMyStream(userID int, since time.Time) ([]CompletePhoto, error) {
+	if db.timelineLength > 0 {
+		return db.getTimeline(userID, since)
+	}
+
 	// Retrieve the list of users that the specified user is following.
 	following, err := db.GetFollowing(userID)
 	if err != nil {
 		return nil, fmt.Errorf("error getting following users: %w", err)
 	}
 
+	// Skip the followed users who banned, or were banned by, the user.
+	banned, err := db.getBannedRelations(userID)
+	if err != nil {
+		return nil, err
+	}
+	following = excludeUsers(following, banned)
+
+	// Skip the followed users muted by the user.
+	muted, err := db.getMutedRelations(userID)
+	if err != nil {
+		return nil, err
+	}
+	following = excludeUsers(following, muted)
+
 	var stream []CompletePhoto
 
 	// Iterate over each followed user to obtain photos from their stream.
 	for _, followedUser := range following {
 		// Retrieve the list of photos uploaded by the followed user.
-		uploadedPhotos, err := db.GetUploadedPhotos(followedUser.UserID)
+		uploadedPhotos, err := db.GetUploadedPhotos(userID, followedUser.UserID)
 		if err != nil {
 			return nil, fmt.Errorf("error getting uploaded photos for user %d: %w", followedUser.UserID, err)
 		}
 
-		// Add the photos to the stream.
-		stream = append(stream, uploadedPhotos...)
+		// Add the photos uploaded after `since` to the stream.
+		for _, photo := range uploadedPhotos {
+			if photo.UploadDate.After(since) {
+				stream = append(stream, photo)
+			}
+		}
 	}
 
 	// Sort the stream in reverse chronological order.
@@ -306,10 +474,10 @@ func (db *appdbimpl) GetMyStream(userID int) ([]CompletePhoto, error) {
 func (db *appdbimpl) GetUsers(userID int, usernameSubstring string) ([]User, error) {
 	var users []User
 
-	// Define SQL query to find users whose usernames contain the specified substring
-	// and who are not banned by the user making the request.
-	query := "SELECT userid, username FROM users WHERE username LIKE ? AND userid NOT IN (SELECT userid FROM banned_users WHERE banneduserid = ?)"
-	rows, err := db.c.Query(query, usernameSubstring+"%", userID)
+	// Define SQL query to find users whose usernames contain the specified substring,
+	// who did not ban, and were not banned by, the user making the request, and who are not suspended.
+	query := "SELECT userid, username FROM users WHERE username LIKE ? AND " + notBannedCondition("userid") + " AND " + notSuspendedCondition("userid")
+	rows, err := db.c.Query(query, usernameSubstring+"%", userID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("error querying users by username substring: %w", err)
 	}
@@ -335,7 +503,7 @@ func (db *appdbimpl) GetUsers(userID int, usernameSubstring string) ([]User, err
 func (db *appdbimpl) GetBanStatus(userID, userToCheckID int) (bool, error) {
 	var hasBanned int
 	// Check if the specified user (userID) has banned the other user (userToCheckID).
-	err := db.c.QueryRow("SELECT 1 FROM banned_users WHERE userid = ? AND banneduserid = ?", userID, userToCheckID).Scan(&hasBanned)
+	err := db.c.QueryRow("SELECT 1 FROM active_bans WHERE userid = ? AND banneduserid = ?", userID, userToCheckID).Scan(&hasBanned)
 	if err == nil {
 		// The user has banned the other user.
 		return true, nil
@@ -344,3 +512,325 @@ func (db *appdbimpl) GetBanStatus(userID, userToCheckID int) (bool, error) {
 	}
 	return false, err
 }
+
+// GetBannedUsers returns a page of the users currently banned by the specified user, most recent bans first.
+func (db *appdbimpl) GetBannedUsers(userID, limit, offset int) ([]Ban, error) {
+	var bans []Ban
+
+	rows, err := db.c.Query(`SELECT b.banneduserid, u.username, b.reason, b.createdAt, b.expiresAt
+		FROM active_bans b JOIN users u ON b.banneduserid = u.userid
+		WHERE b.userid = ?
+		ORDER BY b.createdAt DESC, b.banneduserid LIMIT ? OFFSET ?`, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching banned users: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	// Iterate over the rows to extract each ban's data.
+	for rows.Next() {
+		var ban Ban
+		var reason sql.NullString
+		var createdAt, expiresAt sql.NullTime
+		if err := rows.Scan(&ban.UserID, &ban.Username, &reason, &createdAt, &expiresAt); err != nil {
+			return nil, fmt.Errorf("error scanning banned user row: %w", err)
+		}
+		// Bans created before ban metadata was recorded have no date nor reason.
+		ban.Reason = reason.String
+		ban.CreatedAt = createdAt.Time
+		if expiresAt.Valid {
+			ban.ExpiresAt = &expiresAt.Time
+		}
+		bans = append(bans, ban)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over banned user rows: %w", err)
+	}
+
+	return bans, nil
+}
+
+// MuteUser adds a user to the specified user's muted list.
+func (db *appdbimpl) MuteUser(userID, mutedUserID int) error {
+	// Verify if the user to be muted exists.
+	var existingUser int
+	err := db.c.QueryRow("SELECT 1 FROM users WHERE userid = ?", mutedUserID).Scan(&existingUser)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("the user you want to mute doesn't exists")
+	} else if err != nil {
+		return fmt.Errorf("error checking existing user: %w", err)
+	}
+
+	// Check if the user is trying to mute themselves.
+	if userID == mutedUserID {
+		return errors.New("cannot mute yourself")
+	}
+
+	// Check if the user has already muted the other user.
+	var hasMuted int
+	err = db.c.QueryRow("SELECT 1 FROM muted_users WHERE userid = ? AND muteduserid = ?", userID, mutedUserID).Scan(&hasMuted)
+	if err == nil {
+		return errors.New("you have already muted this user")
+	}
+
+	// Update the muted_users table.
+	_, err = db.c.Exec("INSERT INTO muted_users (userid, muteduserid, createdAt) VALUES (?, ?, ?)", userID, mutedUserID, time.Now())
+	if err != nil {
+		return fmt.Errorf("error updating muted_users table: %w", err)
+	}
+
+	return nil
+}
+
+// UnmuteUser removes a user from the specified user's muted list.
+func (db *appdbimpl) UnmuteUser(userID, mutedUserID int) error {
+	result, err := db.c.Exec("DELETE FROM muted_users WHERE userid = ? AND muteduserid = ?", userID, mutedUserID)
+	if err != nil {
+		return fmt.Errorf("error removing mute: %w", err)
+	}
+
+	// Check if the user was actually muted.
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error removing mute: %w", err)
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetMutedUsers returns a page of the users muted by the specified user, most recently muted first.
+func (db *appdbimpl) GetMutedUsers(userID, limit, offset int) ([]User, error) {
+	var users []User
+
+	rows, err := db.c.Query(`SELECT u.userid, u.username FROM users u JOIN muted_users m ON u.userid = m.muteduserid
+		WHERE m.userid = ? ORDER BY m.createdAt DESC, u.userid LIMIT ? OFFSET ?`, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching muted users: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	// Iterate over the rows to extract each muted user's data.
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.UserID, &user.Username); err != nil {
+			return nil, fmt.Errorf("error scanning muted user row: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over muted user rows: %w", err)
+	}
+
+	return users, nil
+}
+
+// SetPrivate makes the account of the specified user private or public.
+// Making an account public approves all its pending follow requests.
+func (db *appdbimpl) SetPrivate(userID int, private bool) error {
+	// Update the account and approve the pending requests together.
+	return db.withTx(func(tx *appdbimpl) error {
+		_, err := tx.c.Exec("UPDATE users SET private = ? WHERE userid = ?", private, userID)
+		if err != nil {
+			return fmt.Errorf("error updating private in database: %w", err)
+		}
+
+		if private {
+			return nil
+		}
+
+		// Collect the pending requests before approving them, as approving removes them from the table.
+		var requesterIDs []int
+		rows, err := tx.c.Query("SELECT requesterid FROM follow_requests WHERE userid = ?", userID)
+		if err != nil {
+			return fmt.Errorf("error fetching follow requests: %w", err)
+		}
+		defer rows.Close() // Ensure the rows are closed after the query.
+
+		for rows.Next() {
+			var requesterID int
+			if err := rows.Scan(&requesterID); err != nil {
+				return fmt.Errorf("error scanning follow request row: %w", err)
+			}
+			requesterIDs = append(requesterIDs, requesterID)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating over follow request rows: %w", err)
+		}
+
+		for _, requesterID := range requesterIDs {
+			if err := tx.ApproveFollowRequest(userID, requesterID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetFollowRequests returns a page of the users waiting for the specified user to approve their follow request,
+// oldest requests first.
+func (db *appdbimpl) GetFollowRequests(userID, limit, offset int) ([]User, error) {
+	var users []User
+
+	rows, err := db.c.Query(`SELECT u.userid, u.username FROM users u JOIN follow_requests r ON u.userid = r.requesterid
+		WHERE r.userid = ? ORDER BY r.createdAt, u.userid LIMIT ? OFFSET ?`, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching follow requests: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	// Iterate over the rows to extract each requester's data.
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.UserID, &user.Username); err != nil {
+			return nil, fmt.Errorf("error scanning follow request row: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over follow request rows: %w", err)
+	}
+
+	return users, nil
+}
+
+// ApproveFollowRequest accepts the pending follow request sent by requesterID to the specified user.
+func (db *appdbimpl) ApproveFollowRequest(userID, requesterID int) error {
+	// Remove the request and add the follower together.
+	return db.withTx(func(tx *appdbimpl) error {
+		if err := tx.RejectFollowRequest(userID, requesterID); err != nil {
+			return err
+		}
+
+		return tx.addFollower(userID, requesterID)
+	})
+}
+
+// RejectFollowRequest removes the pending follow request sent by requesterID to the specified user.
+func (db *appdbimpl) RejectFollowRequest(userID, requesterID int) error {
+	result, err := db.c.Exec("DELETE FROM follow_requests WHERE userid = ? AND requesterid = ?", userID, requesterID)
+	if err != nil {
+		return fmt.Errorf("error removing follow request: %w", err)
+	}
+
+	// Check if the request actually existed.
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error removing follow request: %w", err)
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	// The request has been answered, so its notification is no longer needed.
+	_, err = db.c.Exec("DELETE FROM notifications WHERE userid = ? AND actorid = ? AND type = ?", userID, requesterID, NotificationFollowRequest)
+	if err != nil {
+		return fmt.Errorf("error removing follow request notification: %w", err)
+	}
+
+	return nil
+}
+
+// IsUserSuspended reports whether the specified user has been suspended.
+func (db *appdbimpl) IsUserSuspended(userID int) (bool, error) {
+	err := db.checkSuspended(userID)
+	if errors.Is(err, ErrUserSuspended) {
+		return true, nil
+	}
+	return false, err
+}
+
+// GetUserRole returns the role of the specified user.
+func (db *appdbimpl) GetUserRole(userID int) (string, error) {
+	var role string
+	err := db.c.QueryRow("SELECT role FROM users WHERE userid = ?", userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", sql.ErrNoRows // User not found
+	} else if err != nil {
+		return "", fmt.Errorf("error fetching user role: %w", err)
+	}
+	return role, nil
+}
+
+// SetUserRole changes the role of the specified user. adminID is the user making the change.
+func (db *appdbimpl) SetUserRole(adminID, userID int, role string) error {
+	if role != RoleUser && role != RoleModerator && role != RoleAdmin {
+		return fmt.Errorf("unknown role %q", role)
+	}
+
+	// Change the role and record the change together.
+	return db.withTx(func(tx *appdbimpl) error {
+		result, err := tx.c.Exec("UPDATE users SET role = ? WHERE userid = ?", role, userID)
+		if err != nil {
+			return fmt.Errorf("error updating user role: %w", err)
+		}
+
+		// Check if the user exists.
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error updating user role: %w", err)
+		}
+		if affected == 0 {
+			return sql.ErrNoRows
+		}
+
+		return tx.logAuditEvent(adminID, AuditRoleChange, AuditTargetUser, userID, role)
+	})
+}
+
+// SetUserSuspended suspends the specified user, or lifts their suspension. moderatorID is the user taking the action.
+func (db *appdbimpl) SetUserSuspended(moderatorID, userID int, suspended bool) error {
+	// Change the suspension and record the change together.
+	return db.withTx(func(tx *appdbimpl) error {
+		result, err := tx.c.Exec("UPDATE users SET suspended = ? WHERE userid = ?", suspended, userID)
+		if err != nil {
+			return fmt.Errorf("error updating user suspension: %w", err)
+		}
+
+		// Check if the user exists.
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error updating user suspension: %w", err)
+		}
+		if affected == 0 {
+			return sql.ErrNoRows
+		}
+
+		action := AuditUserUnsuspend
+		if suspended {
+			action = AuditUserSuspend
+		}
+		return tx.logAuditEvent(moderatorID, action, AuditTargetUser, userID, "")
+	})
+}
+
+// BootstrapAdmin makes the user with the specified username an admin, creating them if needed.
+// It is used at startup to designate the first admin of the platform.
+func (db *appdbimpl) BootstrapAdmin(username string) (User, error) {
+	var user User
+
+	// Promote the user, creating them if needed, and record the change together.
+	err := db.withTx(func(tx *appdbimpl) error {
+		err := tx.c.QueryRow("SELECT userid, username FROM users WHERE LOWER(username) = ?", strings.ToLower(username)).Scan(&user.UserID, &user.Username)
+		if errors.Is(err, sql.ErrNoRows) {
+			user, err = tx.CreateUser(User{Username: username})
+		}
+		if err != nil {
+			return fmt.Errorf("error fetching admin user: %w", err)
+		}
+
+		_, err = tx.c.Exec("UPDATE users SET role = ?, suspended = 0 WHERE userid = ?", RoleAdmin, user.UserID)
+		if err != nil {
+			return fmt.Errorf("error updating admin role: %w", err)
+		}
+
+		return tx.logAuditEvent(0, AuditRoleChange, AuditTargetUser, user.UserID, RoleAdmin+" (startup)")
+	})
+	return user, err
+}
@@ -33,11 +33,11 @@ func (db *appdbimpl) GetUserDetails(userID int) (User, error) {
 	return user, nil
 }
 
-// getFollowers retrieves the list of followers for the specified user.
+// getFollowers retrieves the list of followers for the specified user. Suspended users are not included.
 func (db *appdbimpl) GetFollowers(userID int) ([]User, error) {
 	var followers []User
 
-	rows, err := db.c.Query("SELECT u.userid, u.username FROM users u JOIN followers f ON u.userid = f.followerid WHERE f.userid = ?", userID)
+	rows, err := db.c.Query("SELECT u.userid, u.username FROM users u JOIN followers f ON u.userid = f.followerid WHERE f.userid = ? AND "+notSuspendedCondition("u.userid"), userID)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching followers: %w", err)
 	}
@@ -59,11 +59,39 @@ func (db *appdbimpl) GetFollowers(userID int) ([]User, error) {
 	return followers, nil
 }
 
-// getFollowing retrieves the list of users followed for the specified user.
+// GetUnmutedFollowers retrieves the followers of the specified user who did not mute them. Suspended users are not
+// included.
+func (db *appdbimpl) GetUnmutedFollowers(userID int) ([]User, error) {
+	var followers []User
+
+	rows, err := db.c.Query(`SELECT u.userid, u.username FROM users u JOIN followers f ON u.userid = f.followerid
+		WHERE f.userid = ? AND u.userid NOT IN (SELECT userid FROM muted_users WHERE muteduserid = ?) AND `+notSuspendedCondition("u.userid"), userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching followers: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	// Iterate over the rows to extract each follower's data.
+	for rows.Next() {
+		var follower User
+		if err := rows.Scan(&follower.UserID, &follower.Username); err != nil {
+			return nil, fmt.Errorf("error scanning follower row: %w", err)
+		}
+		followers = append(followers, follower)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over follower rows: %w", err)
+	}
+
+	return followers, nil
+}
+
+// getFollowing retrieves the list of users followed for the specified user. Suspended users are not included.
 func (db *appdbimpl) GetFollowing(userID int) ([]User, error) {
 	var following []User
 
-	rows, err := db.c.Query("SELECT u.userid, u.username FROM users u JOIN following f ON u.userid = f.followingid WHERE f.userid = ?", userID)
+	rows, err := db.c.Query("SELECT u.userid, u.username FROM users u JOIN following f ON u.userid = f.followingid WHERE f.userid = ? AND "+notSuspendedCondition("u.userid"), userID)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching following users: %w", err)
 	}
@@ -85,10 +113,11 @@ func (db *appdbimpl) GetFollowing(userID int) ([]User, error) {
 	return following, nil
 }
 
-// getLikes retrieves the list of likes for the specified photo.
-func (db *appdbimpl) GetLikes(photoID int) ([]Like, error) {
+// getLikes retrieves the list of likes for the specified photo, as seen by the viewer.
+// Likes from users who banned, or were banned by, the viewer, and from suspended users are not included.
+func (db *appdbimpl) GetLikes(viewerID, photoID int) ([]Like, error) {
 	var likes []Like
-	rows, err := db.c.Query("SELECT likeid, userid, photoid FROM likes WHERE photoid = ?", photoID)
+	rows, err := db.c.Query("SELECT l.likeid, l.userid, u.username, l.photoid FROM likes l JOIN users u ON l.userid = u.userid WHERE l.photoid = ? AND "+notBannedCondition("l.userid")+" AND "+notSuspendedCondition("l.userid"), photoID, viewerID, viewerID)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching likes: %w", err)
 	}
@@ -97,7 +126,7 @@ func (db *appdbimpl) GetLikes(photoID int) ([]Like, error) {
 	// Iterate over the rows to extract each like's data.
 	for rows.Next() {
 		var like Like
-		if err := rows.Scan(&like.LikeID, &like.UserID, &like.PhotoID); err != nil {
+		if err := rows.Scan(&like.LikeID, &like.UserID, &like.Username, &like.PhotoID); err != nil {
 			return nil, fmt.Errorf("error scanning like row: %w", err)
 		}
 		likes = append(likes, like)
@@ -110,10 +139,16 @@ func (db *appdbimpl) GetLikes(photoID int) ([]Like, error) {
 	return likes, nil
 }
 
-// getComments retrieves the list of comments for the specified photo.
-func (db *appdbimpl) GetComments(photoID int) ([]Comment, error) {
+// getComments retrieves the list of comments for the specified photo, as seen by the viewer.
+// Hidden comments are only visible to the photo owner and to their author, and comments from users who banned,
+// or were banned by, the viewer, from users muted by the viewer and from suspended users are not included.
+func (db *appdbimpl) GetComments(viewerID, photoID int) ([]Comment, error) {
 	var comments []Comment
-	rows, err := db.c.Query("SELECT commentid, userid, username, photoid, commentText, uploadDate FROM comments WHERE photoid = ?", photoID)
+	rows, err := db.c.Query(`SELECT c.commentid, c.userid, c.username, c.photoid, c.commentText, c.uploadDate, c.editedAt, c.hidden
+		FROM comments c JOIN photos p ON c.photoid = p.photoid
+		WHERE c.photoid = ? AND (c.hidden = 0 OR c.userid = ? OR p.userid = ?)
+		AND `+notBannedCondition("c.userid")+` AND `+notMutedCondition("c.userid")+` AND `+notSuspendedCondition("c.userid"),
+		photoID, viewerID, viewerID, viewerID, viewerID, viewerID)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching comments: %w", err)
 	}
@@ -122,9 +157,13 @@ func (db *appdbimpl) GetComments(photoID int) ([]Comment, error) {
 	// Iterate over the rows to extract each comment's data.
 	for rows.Next() {
 		var comment Comment
-		if err := rows.Scan(&comment.CommentID, &comment.AuthorID, &comment.AuthorUsername, &comment.PhotoID, &comment.CommentText, &comment.UploadDate); err != nil {
+		var editedAt sql.NullTime
+		if err := rows.Scan(&comment.CommentID, &comment.AuthorID, &comment.AuthorUsername, &comment.PhotoID, &comment.CommentText, &comment.UploadDate, &editedAt, &comment.Hidden); err != nil {
 			return nil, fmt.Errorf("error scanning comment row: %w", err)
 		}
+		if editedAt.Valid {
+			comment.EditedAt = &editedAt.Time
+		}
 		comments = append(comments, comment)
 	}
 
@@ -135,12 +174,12 @@ func (db *appdbimpl) GetComments(photoID int) ([]Comment, error) {
 	return comments, nil
 }
 
-// getUploadedPhotos retrieves the list of photos uploaded by the user specified.
-func (db *appdbimpl) GetUploadedPhotos(userID int) ([]CompletePhoto, error) {
+// getUploadedPhotos retrieves the list of photos uploaded by the user specified, as seen by the viewer.
+func (db *appdbimpl) GetUploadedPhotos(viewerID, userID int) ([]CompletePhoto, error) {
 	var uploadedPhotos []CompletePhoto
 
 	// Fetch all photos uploaded by the user, ordered by upload date in descending order.
-	rows, err := db.c.Query("SELECT photoid, userid, username, imageData, uploadDate, likesCount, commentsCount FROM photos WHERE userid = ? ORDER BY uploadDate DESC", userID)
+	rows, err := db.c.Query("SELECT photoid, userid, username, imageData, uploadDate, likesCount, commentsCount, commentsEnabled FROM photos WHERE userid = ? ORDER BY uploadDate DESC", userID)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching uploaded photos: %w", err)
 	}
@@ -149,20 +188,13 @@ func (db *appdbimpl) GetUploadedPhotos(userID int) ([]CompletePhoto, error) {
 	// Iterate over the query results to read each photo's data.
 	for rows.Next() {
 		var photo CompletePhoto
-		if err := rows.Scan(&photo.PhotoID, &photo.UserID, &photo.Username, &photo.ImageData, &photo.UploadDate, &photo.LikesCount, &photo.CommentsCount); err != nil {
+		if err := rows.Scan(&photo.PhotoID, &photo.UserID, &photo.Username, &photo.ImageData, &photo.UploadDate, &photo.LikesCount, &photo.CommentsCount, &photo.CommentsEnabled); err != nil {
 			return nil, fmt.Errorf("error scanning uploaded photo row: %w", err)
 		}
 
-		// Retrieve the list of likes for each photo.
-		photo.Likes, err = db.GetLikes(photo.PhotoID)
-		if err != nil {
-			return nil, fmt.Errorf("error fetching likes for photoID %d: %w", photo.PhotoID, err)
-		}
-
-		// Retrieve the list of comments for each photo.
-		photo.Comments, err = db.GetComments(photo.PhotoID)
-		if err != nil {
-			return nil, fmt.Errorf("error fetching comments for photoID %d: %w", photo.PhotoID, err)
+		// Retrieve the likes and comments for each photo.
+		if err := db.getPhotoDetails(viewerID, &photo); err != nil {
+			return nil, err
 		}
 
 		uploadedPhotos = append(uploadedPhotos, photo)
@@ -174,3 +206,67 @@ func (db *appdbimpl) GetUploadedPhotos(userID int) ([]CompletePhoto, error) {
 
 	return uploadedPhotos, nil
 }
+
+// getPhotoDetails fills the likes and comments of the photo, and whether the viewer liked it.
+func (db *appdbimpl) getPhotoDetails(viewerID int, photo *CompletePhoto) error {
+	var err error
+
+	// Retrieve the list of likes for the photo.
+	photo.Likes, err = db.GetLikes(viewerID, photo.PhotoID)
+	if err != nil {
+		return fmt.Errorf("error fetching likes for photoID %d: %w", photo.PhotoID, err)
+	}
+
+	// Look for the viewer's own like, so they can remove it.
+	for _, like := range photo.Likes {
+		if like.UserID == viewerID {
+			photo.LikedByViewer = true
+			photo.ViewerLikeID = like.LikeID
+			break
+		}
+	}
+
+	// Retrieve the list of comments for the photo.
+	photo.Comments, err = db.GetComments(viewerID, photo.PhotoID)
+	if err != nil {
+		return fmt.Errorf("error fetching comments for photoID %d: %w", photo.PhotoID, err)
+	}
+
+	return nil
+}
+
+// getMutualFollowers returns a page of the users followed by the viewer who also follow the specified user, and their
+// total number. Suspended users are not included.
+func (db *appdbimpl) getMutualFollowers(viewerID, userID, limit, offset int) ([]User, int, error) {
+	var mutual []User
+
+	condition := "f.userid = ? AND f.followerid IN (SELECT followingid FROM following WHERE userid = ?) AND " + notSuspendedCondition("f.followerid")
+
+	var count int
+	err := db.c.QueryRow("SELECT COUNT(*) FROM followers f WHERE "+condition, userID, viewerID).Scan(&count)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error counting mutual followers: %w", err)
+	}
+
+	rows, err := db.c.Query("SELECT u.userid, u.username FROM users u JOIN followers f ON u.userid = f.followerid WHERE "+condition+
+		" ORDER BY u.username LIMIT ? OFFSET ?", userID, viewerID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error fetching mutual followers: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	// Iterate over the rows to extract each mutual follower's data.
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.UserID, &user.Username); err != nil {
+			return nil, 0, fmt.Errorf("error scanning mutual follower row: %w", err)
+		}
+		mutual = append(mutual, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating over mutual follower rows: %w", err)
+	}
+
+	return mutual, count, nil
+}
@@ -0,0 +1,219 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Events that can be delivered to webhooks.
+const (
+	WebhookEventPhotoCreated    = "photo.created"    // A user uploaded a photo
+	WebhookEventFollowerCreated = "follower.created" // A user gained a follower
+	WebhookEventReportCreated   = "report.created"   // Content has been reported, only delivered to admins
+)
+
+// Statuses of a webhook delivery.
+const (
+	WebhookDeliveryPending   = "pending"   // Waiting for the first attempt or for a retry
+	WebhookDeliveryDelivered = "delivered" // Accepted by the webhook
+	WebhookDeliveryDead      = "dead"      // Given up after too many failed attempts
+)
+
+// maxWebhooksPerUser is the maximum number of webhooks a user can register.
+const maxWebhooksPerUser = 10
+
+// CreateWebhook registers a webhook.
+func (db *appdbimpl) CreateWebhook(w Webhook) (Webhook, error) {
+	var count int
+	err := db.c.QueryRow("SELECT COUNT(*) FROM webhooks WHERE ownerid = ?", w.OwnerID).Scan(&count)
+	if err != nil {
+		return w, fmt.Errorf("error counting webhooks: %w", err)
+	}
+	if count >= maxWebhooksPerUser {
+		return w, fmt.Errorf("cannot register more than %d webhooks", maxWebhooksPerUser)
+	}
+
+	result, err := db.c.Exec("INSERT INTO webhooks (ownerid, url, secret, events, createdAt) VALUES (?, ?, ?, ?, ?)",
+		w.OwnerID, w.URL, w.Secret, strings.Join(w.Events, ","), w.CreatedAt)
+	if err != nil {
+		return w, fmt.Errorf("error inserting webhook into database: %w", err)
+	}
+
+	// Get the ID of the newly created webhook.
+	webhookID, err := result.LastInsertId()
+	if err != nil {
+		return w, err
+	}
+	w.WebhookID = int(webhookID)
+
+	return w, nil
+}
+
+// GetWebhooks returns the webhooks registered by the specified user. Secrets are not returned.
+func (db *appdbimpl) GetWebhooks(ownerID int) ([]Webhook, error) {
+	var webhooks []Webhook
+
+	rows, err := db.c.Query("SELECT webhookid, ownerid, url, events, createdAt FROM webhooks WHERE ownerid = ? ORDER BY webhookid", ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching webhooks: %w", err)
+	}
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	// Iterate over the rows to extract each webhook's data.
+	for rows.Next() {
+		var w Webhook
+		var events string
+		if err := rows.Scan(&w.WebhookID, &w.OwnerID, &w.URL, &events, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning webhook row: %w", err)
+		}
+		w.Events = strings.Split(events, ",")
+		webhooks = append(webhooks, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over webhook rows: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook removes a webhook of the specified user, with its delivery log.
+func (db *appdbimpl) DeleteWebhook(ownerID, webhookID int) error {
+	result, err := db.c.Exec("DELETE FROM webhooks WHERE webhookid = ? AND ownerid = ?", webhookID, ownerID)
+	if err != nil {
+		return fmt.Errorf("error removing webhook: %w", err)
+	}
+
+	// Check if the webhook actually existed.
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error removing webhook: %w", err)
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = db.c.Exec("DELETE FROM webhook_deliveries WHERE webhookid = ?", webhookID)
+	if err != nil {
+		return fmt.Errorf("error removing webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// GetWebhookDeliveries returns a page of the delivery log of a webhook of the specified user, most recent first.
+func (db *appdbimpl) GetWebhookDeliveries(ownerID, webhookID, limit, offset int) ([]WebhookDelivery, error) {
+	// Check if the webhook exists and belongs to the user.
+	var existingWebhook int
+	err := db.c.QueryRow("SELECT 1 FROM webhooks WHERE webhookid = ? AND ownerid = ?", webhookID, ownerID).Scan(&existingWebhook)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, sql.ErrNoRows // Webhook not found
+	} else if err != nil {
+		return nil, fmt.Errorf("error checking existing webhook: %w", err)
+	}
+
+	rows, err := db.c.Query(`SELECT d.deliveryid, d.webhookid, d.event, d.payload, d.status, d.attempts, d.nextAttemptAt,
+			d.responseStatus, d.lastError, d.createdAt, d.deliveredAt, w.url, w.secret
+		FROM webhook_deliveries d JOIN webhooks w ON d.webhookid = w.webhookid
+		WHERE d.webhookid = ? ORDER BY d.deliveryid DESC LIMIT ? OFFSET ?`, webhookID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching webhook deliveries: %w", err)
+	}
+	return scanWebhookDeliveries(rows)
+}
+
+// GetDueWebhookDeliveries returns the pending deliveries whose next attempt is due, oldest first, with the URL and
+// secret of their webhook. At most `limit` deliveries are returned for each webhook, so that a webhook with a long
+// queue does not hold back the others. The deliveries queued after a failed one of the same webhook wait for its
+// retry, so that each webhook receives its payloads in order.
+func (db *appdbimpl) GetDueWebhookDeliveries(limit int) ([]WebhookDelivery, error) {
+	now := time.Now().UTC()
+	rows, err := db.c.Query(`SELECT deliveryid, webhookid, event, payload, status, attempts, nextAttemptAt,
+			responseStatus, lastError, createdAt, deliveredAt, url, secret
+		FROM (SELECT d.deliveryid, d.webhookid, d.event, d.payload, d.status, d.attempts, d.nextAttemptAt,
+				d.responseStatus, d.lastError, d.createdAt, d.deliveredAt, w.url, w.secret,
+				ROW_NUMBER() OVER (PARTITION BY d.webhookid ORDER BY d.deliveryid) AS position
+			FROM webhook_deliveries d JOIN webhooks w ON d.webhookid = w.webhookid
+			WHERE d.status = ? AND d.nextAttemptAt <= ?
+				AND NOT EXISTS (SELECT 1 FROM webhook_deliveries e WHERE e.webhookid = d.webhookid AND e.deliveryid < d.deliveryid
+					AND e.status = ? AND e.nextAttemptAt > ?))
+		WHERE position <= ? ORDER BY deliveryid`,
+		WebhookDeliveryPending, now, WebhookDeliveryPending, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching due webhook deliveries: %w", err)
+	}
+	return scanWebhookDeliveries(rows)
+}
+
+// UpdateWebhookDelivery records the outcome of an attempt to deliver a payload.
+func (db *appdbimpl) UpdateWebhookDelivery(d WebhookDelivery) error {
+	var responseStatus sql.NullInt64
+	if d.ResponseStatus != 0 {
+		responseStatus = sql.NullInt64{Int64: int64(d.ResponseStatus), Valid: true}
+	}
+
+	_, err := db.c.Exec(`UPDATE webhook_deliveries SET status = ?, attempts = ?, nextAttemptAt = ?, responseStatus = ?,
+		lastError = ?, deliveredAt = ? WHERE deliveryid = ?`,
+		d.Status, d.Attempts, d.NextAttemptAt.UTC(), responseStatus, d.LastError, d.DeliveredAt, d.DeliveryID)
+	if err != nil {
+		return fmt.Errorf("error updating webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// scanWebhookDeliveries reads the rows of a query on webhook_deliveries joined with webhooks.
+func scanWebhookDeliveries(rows *sql.Rows) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	defer rows.Close() // Ensure the rows are closed after the query.
+
+	for rows.Next() {
+		var d WebhookDelivery
+		var responseStatus sql.NullInt64
+		var lastError sql.NullString
+		var deliveredAt sql.NullTime
+		if err := rows.Scan(&d.DeliveryID, &d.WebhookID, &d.Event, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&responseStatus, &lastError, &d.CreatedAt, &deliveredAt, &d.URL, &d.Secret); err != nil {
+			return nil, fmt.Errorf("error scanning webhook delivery row: %w", err)
+		}
+		d.ResponseStatus = int(responseStatus.Int64)
+		d.LastError = lastError.String
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over webhook delivery rows: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// enqueueWebhookEvent queues a delivery of the event for every webhook subscribed to it: the webhooks of the user the
+// event is about, and the webhooks of the admins, who receive the events of the whole platform. Events not about a
+// single user, such as reports, have a zero userID and are only delivered to the admins.
+func (db *appdbimpl) enqueueWebhookEvent(event string, userID int, data interface{}) error {
+	now := time.Now().UTC()
+	payload, err := json.Marshal(struct {
+		Event     string      `json:"event"`
+		CreatedAt time.Time   `json:"createdAt"`
+		Data      interface{} `json:"data"`
+	}{event, now, data})
+	if err != nil {
+		return fmt.Errorf("error encoding webhook payload: %w", err)
+	}
+
+	_, err = db.c.Exec(`INSERT INTO webhook_deliveries (webhookid, event, payload, status, nextAttemptAt, createdAt)
+		SELECT w.webhookid, ?, ?, ?, ?, ? FROM webhooks w JOIN users u ON w.ownerid = u.userid
+		WHERE (',' || w.events || ',') LIKE ? AND (w.ownerid = ? OR u.role = ?) AND u.suspended = 0`,
+		event, string(payload), WebhookDeliveryPending, now, now, "%,"+event+",%", userID, RoleAdmin)
+	if err != nil {
+		return fmt.Errorf("error queueing webhook deliveries: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package mail
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/database"
+	"github.com/sirupsen/logrus"
+)
+
+// digestItems lists the kinds of notifications summarized in the digests, in order, with their singular and plural
+// descriptions.
+var digestItems = []struct {
+	notificationType string
+	singular, plural string
+}{
+	{database.NotificationLike, "like", "likes"},
+	{database.NotificationComment, "comment", "comments"},
+	{database.NotificationMention, "mention", "mentions"},
+	{database.NotificationFollow, "new follower", "new followers"},
+	{database.NotificationFollowRequest, "follow request", "follow requests"},
+}
+
+// digestPeriodNames describes the period covered by the digests of each frequency.
+var digestPeriodNames = map[string]string{
+	database.DigestDaily:  "today",
+	database.DigestWeekly: "this week",
+}
+
+// DigestJob periodically emails the users a summary of their unread notifications, until it is closed.
+type DigestJob struct {
+	db       database.AppDatabase
+	mailer   Mailer
+	logger   logrus.FieldLogger
+	interval time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewDigestJob starts a DigestJob checking for due digests at the specified interval, and right away.
+func NewDigestJob(db database.AppDatabase, mailer Mailer, logger logrus.FieldLogger, interval time.Duration) *DigestJob {
+	j := &DigestJob{
+		db:       db,
+		mailer:   mailer,
+		logger:   logger,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go j.run()
+	return j
+}
+
+// Close stops the DigestJob, and waits for the digests being sent.
+func (j *DigestJob) Close() {
+	close(j.stop)
+	<-j.done
+}
+
+// run sends the due digests periodically, until the job is stopped.
+func (j *DigestJob) run() {
+	defer close(j.done)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.sendDue()
+
+		select {
+		case <-j.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendDue sends the digests that are due. Digests without notifications are not sent, but their period is marked as
+// covered; digests that cannot be sent are retried at the next check.
+func (j *DigestJob) sendDue() {
+	now := time.Now()
+	digests, err := j.db.GetDueDigests(now)
+	if err != nil {
+		j.logger.WithError(err).Error("digests: error fetching due digests")
+		return
+	}
+
+	for _, d := range digests {
+		select {
+		case <-j.stop:
+			return
+		default:
+		}
+
+		if msg, ok := digestMessage(d); ok {
+			if err := j.mailer.Send(msg); err != nil {
+				j.logger.WithError(err).Errorf("digests: error sending the digest of user %d", d.UserID)
+				continue
+			}
+		}
+
+		if err := j.db.SetDigestSent(d.UserID, now); err != nil {
+			j.logger.WithError(err).Error("digests: error recording sent digest")
+		}
+	}
+}
+
+// digestMessage composes the email of a digest, such as "You got 12 likes and 2 new followers this week". It returns
+// false if there is nothing to summarize.
+func digestMessage(d database.Digest) (Message, bool) {
+	var items []string
+	for _, item := range digestItems {
+		switch count := d.Counts[item.notificationType]; {
+		case count == 1:
+			items = append(items, "1 "+item.singular)
+		case count > 1:
+			items = append(items, fmt.Sprintf("%d %s", count, item.plural))
+		}
+	}
+	if len(items) == 0 {
+		return Message{}, false
+	}
+
+	summary := items[0]
+	if len(items) > 1 {
+		summary = strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+	}
+
+	var body strings.Builder
+	_, _ = fmt.Fprintf(&body, "Hi %s,\r\n\r\n", d.Username)
+	_, _ = fmt.Fprintf(&body, "You got %s %s.\r\n\r\n", summary, digestPeriodNames[d.Frequency])
+	body.WriteString("Open WASAPhoto to see them.\r\n\r\n")
+	body.WriteString("You receive this email because you turned on the email digests. You can turn them off in your profile settings.\r\n")
+
+	return Message{
+		To:      d.Email,
+		Subject: fmt.Sprintf("Your WASAPhoto %s digest", d.Frequency),
+		Body:    body.String(),
+	}, true
+}
@@ -0,0 +1,63 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fileSender is the sender of the emails written by FileMailer.
+const fileSender = "WASAPhoto <noreply@localhost>"
+
+// FileMailer appends the emails to a file instead of sending them, for local development.
+type FileMailer struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileMailer returns a Mailer appending the emails to the file at the specified path, which is created if missing.
+func NewFileMailer(path string) *FileMailer {
+	return &FileMailer{path: path}
+}
+
+// Send appends the message to the file, followed by a separator line.
+func (m *FileMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fp, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening mail file: %w", err)
+	}
+
+	_, err = fmt.Fprintf(fp, "%s\r\n----\r\n", msg.format(fileSender, time.Now()))
+	if closeErr := fp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing mail file: %w", err)
+	}
+	return nil
+}
+
+// LogMailer writes the emails to the log instead of sending them, for local development.
+type LogMailer struct {
+	logger logrus.FieldLogger
+}
+
+// NewLogMailer returns a Mailer writing the emails to the specified logger.
+func NewLogMailer(logger logrus.FieldLogger) *LogMailer {
+	return &LogMailer{logger: logger}
+}
+
+// Send writes the message to the log.
+func (m *LogMailer) Send(msg Message) error {
+	m.logger.WithFields(logrus.Fields{
+		"to":      msg.To,
+		"subject": msg.Subject,
+	}).Info(msg.Body)
+	return nil
+}
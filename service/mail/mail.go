@@ -0,0 +1,41 @@
+/*
+Package mail sends emails to the users: the Mailer interface hides how messages are delivered, so that an SMTP server
+can be used in production, and a file or the log during local development.
+
+The package also contains the DigestJob, which periodically emails the users a summary of their unread notifications.
+*/
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"time"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails.
+type Mailer interface {
+	// Send delivers the message, or returns an error if it cannot be delivered.
+	Send(Message) error
+}
+
+// format returns the message in the Internet Message Format, with the specified sender.
+func (m Message) format(from string, date time.Time) []byte {
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, "From: %s\r\n", from)
+	_, _ = fmt.Fprintf(&buf, "To: %s\r\n", m.To)
+	_, _ = fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	_, _ = fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(m.Body)
+	return buf.Bytes()
+}
@@ -0,0 +1,93 @@
+package mail
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// smtpTimeout bounds the time spent sending a message, from connecting to the SMTP server to closing the connection,
+// so that a stalled server does not block the digests, nor the shutdown.
+const smtpTimeout = 30 * time.Second
+
+// SMTPMailer sends the emails through an SMTP server.
+type SMTPMailer struct {
+	host     string
+	addr     string
+	auth     smtp.Auth
+	from     string // Sender shown in the From header, such as "WASAPhoto <noreply@example.com>"
+	envelope string // Address of the sender
+}
+
+// NewSMTPMailer returns a Mailer using the SMTP server at the specified host and port, sending the emails from the
+// specified address. If the username is empty, no authentication is performed.
+func NewSMTPMailer(host string, port int, username, password, from string) (*SMTPMailer, error) {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	m := &SMTPMailer{
+		host:     host,
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		from:     sender.String(),
+		envelope: sender.Address,
+	}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m, nil
+}
+
+// Send delivers the message to the SMTP server, using STARTTLS when the server supports it. It gives up after
+// smtpTimeout.
+func (m *SMTPMailer) Send(msg Message) error {
+	conn, err := net.DialTimeout("tcp", m.addr, smtpTimeout)
+	if err != nil {
+		return fmt.Errorf("error connecting to the SMTP server: %w", err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(smtpTimeout)); err != nil {
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return fmt.Errorf("error starting the SMTP session: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("error starting TLS: %w", err)
+		}
+	}
+	if m.auth != nil {
+		if err := c.Auth(m.auth); err != nil {
+			return fmt.Errorf("error authenticating to the SMTP server: %w", err)
+		}
+	}
+
+	if err := c.Mail(m.envelope); err != nil {
+		return err
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg.format(m.from, time.Now())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
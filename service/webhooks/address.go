@@ -0,0 +1,53 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+)
+
+// ErrForbiddenAddress is returned for the webhooks whose host resolves to an address of the internal network of the
+// server, which webhooks are not allowed to reach.
+var ErrForbiddenAddress = errors.New("webhook address not allowed")
+
+// allowedAddress reports whether payloads can be delivered to the IP address: loopback, link-local, private and
+// unspecified addresses are refused.
+func allowedAddress(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsPrivate() && !ip.IsUnspecified()
+}
+
+// CheckURL resolves the host of a webhook URL, returning ErrForbiddenAddress if any of its addresses is not allowed.
+// The addresses are checked again when delivering, as the host may resolve differently by then.
+func CheckURL(ctx context.Context, rawURL string) error {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, target.Hostname())
+	if err != nil {
+		return fmt.Errorf("error resolving webhook host: %w", err)
+	}
+	for _, addr := range addrs {
+		if !allowedAddress(addr.IP) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
+
+// dialControl refuses the connections to addresses that are not allowed. It runs after the host is resolved, right
+// before connecting, so it also covers redirects and hosts whose addresses changed after the webhook was registered.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !allowedAddress(ip) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+/*
+Package webhooks delivers the events of the platform to the webhooks registered by the users.
+
+Deliveries are queued in the database by the database package, and sent by a Worker running in the background. Each
+payload is a JSON object sent with a POST request, signed with HMAC-SHA256 using the secret of the webhook:
+
+	X-WASAPhoto-Event: photo.created
+	X-WASAPhoto-Delivery: 42
+	X-WASAPhoto-Signature: sha256=<hex-encoded HMAC of the body>
+
+Any 2xx response marks the delivery as delivered. Failed deliveries are retried with exponential backoff, and are
+marked as dead after MaxAttempts attempts. The deliveries of each webhook are sent in order: a failed delivery holds
+back the following ones until it is delivered or dead. Different webhooks are served concurrently, so that a slow
+endpoint does not delay the others. Webhooks cannot reach loopback, link-local, private or unspecified addresses.
+*/
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"git.sapienzaapps.it/fantasticcoffee/fantastic-coffee-decaffeinated/service/database"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// MaxAttempts is the number of attempts after which a delivery is marked as dead.
+	MaxAttempts = 8
+
+	// retryBaseDelay is the delay before the first retry; it doubles after every failed attempt.
+	retryBaseDelay = 30 * time.Second
+
+	// pollInterval is how often the queue is checked for due deliveries.
+	pollInterval = 2 * time.Second
+
+	// batchSize is the maximum number of deliveries of each webhook sent at every check.
+	batchSize = 10
+
+	// maxConcurrentWebhooks is the maximum number of webhooks whose deliveries are sent at the same time.
+	maxConcurrentWebhooks = 8
+
+	// requestTimeout is the maximum duration of a delivery request.
+	requestTimeout = 10 * time.Second
+)
+
+// Worker sends the queued deliveries in the background, until it is closed.
+type Worker struct {
+	db     database.AppDatabase
+	logger logrus.FieldLogger
+	client *http.Client
+
+	// slots bounds the number of webhooks served at the same time, and busy holds the IDs of the webhooks being served,
+	// which are skipped until their deliveries are sent.
+	slots chan struct{}
+	mu    sync.Mutex
+	busy  map[int]bool
+	wg    sync.WaitGroup
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewWorker starts a Worker sending the deliveries queued in the database.
+func NewWorker(db database.AppDatabase, logger logrus.FieldLogger) *Worker {
+	ctx, cancel := context.WithCancel(context.Background())
+	dialer := &net.Dialer{Timeout: requestTimeout, Control: dialControl}
+	w := &Worker{
+		db:     db,
+		logger: logger,
+		client: &http.Client{
+			Timeout: requestTimeout,
+			// No proxy, so that the address being dialed is the one of the webhook.
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+		},
+		slots:  make(chan struct{}, maxConcurrentWebhooks),
+		busy:   make(map[int]bool),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go w.run(ctx)
+	return w
+}
+
+// Close stops the Worker, interrupting the deliveries in progress, and waits for it to exit. Interrupted deliveries are
+// retried when a Worker is started again.
+func (w *Worker) Close() {
+	w.cancel()
+	<-w.done
+}
+
+// run checks the queue periodically, until the context is canceled.
+func (w *Worker) run(ctx context.Context) {
+	defer close(w.done)
+	defer w.wg.Wait()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.deliverDue(ctx)
+		}
+	}
+}
+
+// deliverDue starts sending the deliveries whose next attempt is due, grouped by webhook. Webhooks already being
+// served, or beyond the maximum number of concurrent webhooks, are left for the next check.
+func (w *Worker) deliverDue(ctx context.Context) {
+	deliveries, err := w.db.GetDueWebhookDeliveries(batchSize)
+	if err != nil {
+		w.logger.WithError(err).Error("webhooks: error fetching due deliveries")
+		return
+	}
+
+	var order []int
+	groups := make(map[int][]database.WebhookDelivery)
+	for _, d := range deliveries {
+		if groups[d.WebhookID] == nil {
+			order = append(order, d.WebhookID)
+		}
+		groups[d.WebhookID] = append(groups[d.WebhookID], d)
+	}
+
+	for _, webhookID := range order {
+		w.mu.Lock()
+		busy := w.busy[webhookID]
+		w.mu.Unlock()
+		if busy {
+			continue
+		}
+
+		select {
+		case w.slots <- struct{}{}:
+		default:
+			return // All the slots are taken.
+		}
+
+		w.mu.Lock()
+		w.busy[webhookID] = true
+		w.mu.Unlock()
+
+		w.wg.Add(1)
+		go func(webhookID int, deliveries []database.WebhookDelivery) {
+			defer func() {
+				w.mu.Lock()
+				delete(w.busy, webhookID)
+				w.mu.Unlock()
+				<-w.slots
+				w.wg.Done()
+			}()
+			w.deliverAll(ctx, deliveries)
+		}(webhookID, groups[webhookID])
+	}
+}
+
+// deliverAll sends the deliveries of a webhook in order, recording the outcome of each attempt.
+func (w *Worker) deliverAll(ctx context.Context, deliveries []database.WebhookDelivery) {
+	for _, d := range deliveries {
+		status, err := w.send(ctx, d)
+		if ctx.Err() != nil {
+			// Shutting down: the delivery stays pending.
+			return
+		}
+
+		d.Attempts++
+		d.ResponseStatus = status
+		if err == nil {
+			now := time.Now()
+			d.Status = database.WebhookDeliveryDelivered
+			d.LastError = ""
+			d.DeliveredAt = &now
+		} else {
+			w.logger.WithError(err).Debugf("webhooks: delivery %d failed", d.DeliveryID)
+			d.LastError = publicError(status, err)
+			if d.Attempts >= MaxAttempts {
+				d.Status = database.WebhookDeliveryDead
+				w.logger.WithError(err).Warningf("webhooks: delivery %d is dead after %d attempts", d.DeliveryID, d.Attempts)
+			} else {
+				d.NextAttemptAt = time.Now().Add(retryDelay(d.Attempts))
+			}
+		}
+
+		if err := w.db.UpdateWebhookDelivery(d); err != nil {
+			w.logger.WithError(err).Error("webhooks: error recording delivery attempt")
+		}
+
+		if d.Status == database.WebhookDeliveryPending {
+			// The next deliveries wait for the retry of this one, to keep the order.
+			return
+		}
+	}
+}
+
+// publicError returns the description of a failed attempt shown to the owner of the webhook. The details of network
+// errors are left out, as they may reveal the internal network of the server.
+func publicError(status int, err error) string {
+	var netErr net.Error
+	switch {
+	case status != 0:
+		return err.Error()
+	case errors.Is(err, ErrForbiddenAddress):
+		return ErrForbiddenAddress.Error()
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "request timed out"
+	default:
+		return "request failed"
+	}
+}
+
+// retryDelay returns the delay before the next attempt, after the given number of failed attempts.
+func retryDelay(attempts int) time.Duration {
+	return retryBaseDelay << (attempts - 1)
+}
+
+// send posts the payload of a delivery to its webhook, and returns the HTTP status of the response, if any.
+func (w *Worker) send(ctx context.Context, d database.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewBufferString(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-WASAPhoto-Event", d.Event)
+	req.Header.Set("X-WASAPhoto-Delivery", strconv.Itoa(d.DeliveryID))
+	req.Header.Set("X-WASAPhoto-Signature", "sha256="+Sign(d.Secret, []byte(d.Payload)))
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected response status %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of the payload, computed with the secret of the webhook. Receivers compute
+// it on the request body and compare it with the X-WASAPhoto-Signature header.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}